@@ -0,0 +1,129 @@
+// Package client is a typed Go client for the PingMe API. It speaks the
+// default snake_case envelope, so servers must not set JSON_CASE=camel.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Caleb125-source/pingme-api/pingme"
+)
+
+// Client calls a PingMe API server
+type Client struct {
+	// BaseURL is the server's root, e.g. "http://localhost:8080" or, behind
+	// PATH_PREFIX, "http://localhost:8080/pingme"
+	BaseURL string
+	// HTTPClient sends the requests; nil uses http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// New returns a Client for the server at baseURL using http.DefaultClient
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Error is a failed API call. Code is the envelope's error_code, which is
+// stable across releases; Message is meant for people.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	// Data is the envelope's data, if the error carried any
+	Data json.RawMessage
+}
+
+// Error describes the failure with its status and code
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("pingme: status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("pingme: status %d (%s): %s", e.StatusCode, e.Code, e.Message)
+}
+
+// envelope is the Response shape as received, with data left undecoded
+type envelope struct {
+	Success   bool            `json:"success"`
+	Data      json.RawMessage `json:"data"`
+	Error     string          `json:"error"`
+	ErrorCode string          `json:"error_code"`
+}
+
+// Echo sends message to POST /echo and returns the echoed data
+func (c *Client) Echo(ctx context.Context, message string) (*pingme.EchoData, error) {
+	var data pingme.EchoData
+	if err := c.do(ctx, http.MethodPost, "/echo", pingme.EchoRequest{Message: message}, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// Greeting fetches GET /
+func (c *Client) Greeting(ctx context.Context) (*pingme.GreetingData, error) {
+	var data pingme.GreetingData
+	if err := c.do(ctx, http.MethodGet, "/", nil, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// Health fetches GET /healthz. A degraded server still succeeds, with
+// Status "degraded"; an unhealthy one returns an *Error whose Data holds
+// the failing checks.
+func (c *Client) Health(ctx context.Context) (*pingme.HealthData, error) {
+	var data pingme.HealthData
+	if err := c.do(ctx, http.MethodGet, "/healthz", nil, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// do sends a request with an optional JSON body and decodes the envelope's
+// data into out, turning unsuccessful envelopes into *Error
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("pingme: encoding request: %w", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("pingme: building request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("pingme: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		// Not an envelope, e.g. a proxy error page
+		return &Error{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	}
+	if !env.Success || resp.StatusCode >= 400 {
+		return &Error{StatusCode: resp.StatusCode, Code: env.ErrorCode, Message: env.Error, Data: env.Data}
+	}
+	if err := json.Unmarshal(env.Data, out); err != nil {
+		return fmt.Errorf("pingme: decoding %s %s data: %w", method, path, err)
+	}
+	return nil
+}
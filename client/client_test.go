@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Caleb125-source/pingme-api/pingme"
+)
+
+// newTestClient starts the real handlers on an httptest server and returns a Client for it
+func newTestClient(t *testing.T, api *pingme.Server) *Client {
+	t.Helper()
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	return New(server.URL + "/")
+}
+
+// TestEcho tests that Echo returns the typed echo data
+func TestEcho(t *testing.T) {
+	c := newTestClient(t, pingme.NewServer(pingme.DefaultConfig()))
+
+	data, err := c.Echo(context.Background(), "Hello, PingMe!")
+	if err != nil {
+		t.Fatalf("Echo failed: %v", err)
+	}
+	if data.Original != "Hello, PingMe!" || data.Echoed != "Echo: Hello, PingMe!" || data.Length != 14 {
+		t.Errorf("unexpected echo data %+v", data)
+	}
+	if data.Timestamp.IsZero() {
+		t.Error("expected a timestamp")
+	}
+}
+
+// TestEchoError tests that a rejected echo returns an *Error carrying the error code
+func TestEchoError(t *testing.T) {
+	c := newTestClient(t, pingme.NewServer(pingme.DefaultConfig()))
+
+	_, err := c.Echo(context.Background(), "")
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an *Error, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != "empty_message" {
+		t.Errorf("expected 400 empty_message, got %d %s", apiErr.StatusCode, apiErr.Code)
+	}
+	if apiErr.Message != "Message field cannot be empty" {
+		t.Errorf("unexpected message %q", apiErr.Message)
+	}
+}
+
+// TestGreeting tests that Greeting returns the configured greeting
+func TestGreeting(t *testing.T) {
+	cfg := pingme.DefaultConfig()
+	cfg.Greeting = "Hi from the SDK"
+	c := newTestClient(t, pingme.NewServer(cfg))
+
+	data, err := c.Greeting(context.Background())
+	if err != nil {
+		t.Fatalf("Greeting failed: %v", err)
+	}
+	if data.Greeting != "Hi from the SDK" {
+		t.Errorf("expected the configured greeting, got %q", data.Greeting)
+	}
+}
+
+// TestHealth tests healthy and unhealthy servers
+func TestHealth(t *testing.T) {
+	c := newTestClient(t, pingme.NewServer(pingme.DefaultConfig()))
+
+	data, err := c.Health(context.Background())
+	if err != nil {
+		t.Fatalf("Health failed: %v", err)
+	}
+	if data.Status != "healthy" {
+		t.Errorf("expected status healthy, got %q", data.Status)
+	}
+
+	api := pingme.NewServer(pingme.DefaultConfig())
+	api.RegisterHealthCheck("datastore", pingme.HealthCheckFunc(func(context.Context) error {
+		return errors.New("connection refused")
+	}))
+	c = newTestClient(t, api)
+
+	_, err = c.Health(context.Background())
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an *Error, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusServiceUnavailable || apiErr.Code != "unhealthy" {
+		t.Errorf("expected 503 unhealthy, got %d %s", apiErr.StatusCode, apiErr.Code)
+	}
+	if len(apiErr.Data) == 0 {
+		t.Error("expected the failing checks in Data")
+	}
+}
+
+// TestNotEnvelope tests that a non-JSON response becomes an *Error with the status
+func TestNotEnvelope(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL).Greeting(context.Background())
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("expected a 502 *Error, got %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+// Echo service mirroring POST /echo, served on GRPC_PORT when ENABLE_GRPC
+// is true. The Go stubs in gen/pingme/v1 are generated with protoc-gen-go
+// and protoc-gen-go-grpc; regenerate them after changing this file.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.35.2
+// 	protoc        (unknown)
+// source: pingme/v1/echo.proto
+
+package pingmev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type EchoRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Message string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	// Comma-separated transforms applied left to right: upper, lower,
+	// reverse, rot13, hex, hexdecode, mask, sha256, trim, nfc, nfd
+	Mode string `protobuf:"bytes,2,opt,name=mode,proto3" json:"mode,omitempty"`
+}
+
+func (x *EchoRequest) Reset() {
+	*x = EchoRequest{}
+	mi := &file_pingme_v1_echo_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EchoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EchoRequest) ProtoMessage() {}
+
+func (x *EchoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pingme_v1_echo_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EchoRequest.ProtoReflect.Descriptor instead.
+func (*EchoRequest) Descriptor() ([]byte, []int) {
+	return file_pingme_v1_echo_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EchoRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *EchoRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+type EchoResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Original string `protobuf:"bytes,1,opt,name=original,proto3" json:"original,omitempty"`
+	Echoed   string `protobuf:"bytes,2,opt,name=echoed,proto3" json:"echoed,omitempty"`
+	Length   int32  `protobuf:"varint,3,opt,name=length,proto3" json:"length,omitempty"`
+}
+
+func (x *EchoResponse) Reset() {
+	*x = EchoResponse{}
+	mi := &file_pingme_v1_echo_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EchoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EchoResponse) ProtoMessage() {}
+
+func (x *EchoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pingme_v1_echo_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EchoResponse.ProtoReflect.Descriptor instead.
+func (*EchoResponse) Descriptor() ([]byte, []int) {
+	return file_pingme_v1_echo_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *EchoResponse) GetOriginal() string {
+	if x != nil {
+		return x.Original
+	}
+	return ""
+}
+
+func (x *EchoResponse) GetEchoed() string {
+	if x != nil {
+		return x.Echoed
+	}
+	return ""
+}
+
+func (x *EchoResponse) GetLength() int32 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+var File_pingme_v1_echo_proto protoreflect.FileDescriptor
+
+var file_pingme_v1_echo_proto_rawDesc = []byte{
+	0x0a, 0x14, 0x70, 0x69, 0x6e, 0x67, 0x6d, 0x65, 0x2f, 0x76, 0x31, 0x2f, 0x65, 0x63, 0x68, 0x6f,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x09, 0x70, 0x69, 0x6e, 0x67, 0x6d, 0x65, 0x2e, 0x76,
+	0x31, 0x22, 0x3b, 0x0a, 0x0b, 0x45, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x6f,
+	0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x22, 0x5a,
+	0x0a, 0x0c, 0x45, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a,
+	0x0a, 0x08, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x61, 0x6c, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x63,
+	0x68, 0x6f, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x65, 0x63, 0x68, 0x6f,
+	0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x06, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x32, 0x46, 0x0a, 0x0b, 0x45, 0x63,
+	0x68, 0x6f, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x37, 0x0a, 0x04, 0x45, 0x63, 0x68,
+	0x6f, 0x12, 0x16, 0x2e, 0x70, 0x69, 0x6e, 0x67, 0x6d, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x63,
+	0x68, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x70, 0x69, 0x6e, 0x67,
+	0x6d, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x42, 0x3e, 0x5a, 0x3c, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x43, 0x61, 0x6c, 0x65, 0x62, 0x31, 0x32, 0x35, 0x2d, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x2f, 0x70, 0x69, 0x6e, 0x67, 0x6d, 0x65, 0x2d, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x65, 0x6e, 0x2f,
+	0x70, 0x69, 0x6e, 0x67, 0x6d, 0x65, 0x2f, 0x76, 0x31, 0x3b, 0x70, 0x69, 0x6e, 0x67, 0x6d, 0x65,
+	0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_pingme_v1_echo_proto_rawDescOnce sync.Once
+	file_pingme_v1_echo_proto_rawDescData = file_pingme_v1_echo_proto_rawDesc
+)
+
+func file_pingme_v1_echo_proto_rawDescGZIP() []byte {
+	file_pingme_v1_echo_proto_rawDescOnce.Do(func() {
+		file_pingme_v1_echo_proto_rawDescData = protoimpl.X.CompressGZIP(file_pingme_v1_echo_proto_rawDescData)
+	})
+	return file_pingme_v1_echo_proto_rawDescData
+}
+
+var file_pingme_v1_echo_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_pingme_v1_echo_proto_goTypes = []any{
+	(*EchoRequest)(nil),  // 0: pingme.v1.EchoRequest
+	(*EchoResponse)(nil), // 1: pingme.v1.EchoResponse
+}
+var file_pingme_v1_echo_proto_depIdxs = []int32{
+	0, // 0: pingme.v1.EchoService.Echo:input_type -> pingme.v1.EchoRequest
+	1, // 1: pingme.v1.EchoService.Echo:output_type -> pingme.v1.EchoResponse
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_pingme_v1_echo_proto_init() }
+func file_pingme_v1_echo_proto_init() {
+	if File_pingme_v1_echo_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_pingme_v1_echo_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pingme_v1_echo_proto_goTypes,
+		DependencyIndexes: file_pingme_v1_echo_proto_depIdxs,
+		MessageInfos:      file_pingme_v1_echo_proto_msgTypes,
+	}.Build()
+	File_pingme_v1_echo_proto = out.File
+	file_pingme_v1_echo_proto_rawDesc = nil
+	file_pingme_v1_echo_proto_goTypes = nil
+	file_pingme_v1_echo_proto_depIdxs = nil
+}
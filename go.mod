@@ -1,3 +1,17 @@
 module github.com/Caleb125-source/pingme-api
 
 go 1.22.2
+
+require (
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	golang.org/x/text v0.22.0
+	google.golang.org/grpc v1.68.2
+	google.golang.org/protobuf v1.35.2
+)
+
+require (
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	golang.org/x/net v0.29.0 // indirect
+	golang.org/x/sys v0.25.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240903143218-8af14fe29dc1 // indirect
+)
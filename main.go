@@ -1,199 +1,336 @@
 package main
 
 import (
-	"encoding/json"
-	"fmt"
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
+
+	"github.com/Caleb125-source/pingme-api/pingme"
+)
+
+// Process exit codes, so supervisors can tell bind failures from runtime failures
+const (
+	exitRuntimeError = 1
+	exitBindError    = 2
+	exitUsageError   = 64
 )
 
-// Response represents the standard JSON response structure
-type Response struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+// defaultPort is used when PORT is unset; override it at build time with
+// -ldflags "-X main.defaultPort=9000"
+var defaultPort = "8080"
+
+// newServer creates and configures the HTTP server - extracted for testability
+func newServer(cfg pingme.Config) *http.Server {
+	api := pingme.NewServer(cfg)
+	server := &http.Server{
+		Addr:              net.JoinHostPort(cfg.Bind, cfg.Port),
+		Handler:           api,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ConnState:         api.ConnState,
+	}
+	if cfg.DisableKeepAlive {
+		server.SetKeepAlivesEnabled(false)
+	}
+	return server
 }
 
-// EchoRequest represents the expected JSON input for the echo endpoint
-type EchoRequest struct {
-	Message string `json:"message"`
+// getPort returns the port from environment variable or default
+func getPort() string {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = defaultPort
+	}
+	return port
 }
 
-// EchoData represents the data returned by the echo endpoint
-type EchoData struct {
-	Original  string    `json:"original"`
-	Echoed    string    `json:"echoed"`
-	Length    int       `json:"length"`
-	Timestamp time.Time `json:"timestamp"`
+// flagArgs are the command-line arguments main parsed, reapplied on every
+// reload so flags keep overriding the environment
+var flagArgs []string
+
+// reapplyFlags puts the startup flags back over a config re-read from the environment
+func reapplyFlags(cfg *pingme.Config) {
+	cfg.Port = getPort()
+	// The same arguments parsed cleanly at startup
+	*cfg, _ = parseFlags(flagArgs, *cfg)
 }
 
-// GreetingData represents the data returned by the greeting endpoint
-type GreetingData struct {
-	Greeting  string    `json:"greeting"`
-	Timestamp time.Time `json:"timestamp"`
+// parseFlags applies command-line flags on top of cfg, which already holds
+// the defaults overridden by the environment, so flags take precedence
+func parseFlags(args []string, cfg pingme.Config) (pingme.Config, error) {
+	fs := flag.NewFlagSet("pingme-api", flag.ContinueOnError)
+	fs.StringVar(&cfg.Port, "port", cfg.Port, "port to listen on (overrides PORT)")
+	fs.StringVar(&cfg.Bind, "bind", cfg.Bind, "interface address to listen on, empty for all (overrides BIND)")
+	fs.StringVar(&cfg.LogLevel, "log-level", cfg.LogLevel, "debug, info, warn or error (overrides LOG_LEVEL)")
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
 }
 
-// HealthData represents the data returned by the health check endpoint
-type HealthData struct {
-	Status string    `json:"status"`
-	Time   time.Time `json:"time"`
+// logRoutes logs every route from the server's route table, so the list
+// can't drift from what is actually registered
+func logRoutes(routes []pingme.RouteInfo) {
+	args := make([]interface{}, 0, 2*len(routes))
+	for _, rt := range routes {
+		args = append(args, strings.Join(rt.Methods, ",")+" "+rt.Path, rt.Description)
+	}
+	slog.Info("Endpoints available", args...)
 }
 
-// respondJSON sends a JSON response with the specified status code
-func respondJSON(w http.ResponseWriter, statusCode int, response Response) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding JSON response: %v", err)
+// logFile is the LOG_FILE destination when logs go to a file; SIGHUP reopens it
+var logFile *pingme.LogFile
+
+// openLogOutput returns the writer for Config.LogFile: stdout, stderr, or a
+// file opened for appending, which is also returned so it can be reopened
+func openLogOutput(path string) (io.Writer, *pingme.LogFile, error) {
+	switch path {
+	case "", "stderr":
+		return os.Stderr, nil, nil
+	case "stdout":
+		return os.Stdout, nil, nil
+	}
+	file, err := pingme.OpenLogFile(path)
+	if err != nil {
+		return os.Stderr, nil, err
 	}
+	return file, file, nil
 }
 
-// greetingHandler handles GET requests to the root endpoint
-func greetingHandler(w http.ResponseWriter, r *http.Request) {
-	// Only allow GET requests
-	if r.Method != http.MethodGet {
-		respondJSON(w, http.StatusMethodNotAllowed, Response{
-			Success: false,
-			Error:   "Method not allowed. Use GET.",
-		})
-		return
+// listenConfig returns the listener settings for cfg, setting SO_REUSEPORT
+// when ReusePort is on
+func listenConfig(cfg pingme.Config) *net.ListenConfig {
+	lc := &net.ListenConfig{}
+	if cfg.ReusePort {
+		lc.Control = reusePort
 	}
+	return lc
+}
 
-	// Create greeting response
-	data := GreetingData{
-		Greeting:  "Welcome to PingMe API!",
-		Timestamp: time.Now().UTC(),
+// drainLogInterval is how often shutdown reports connections still open
+var drainLogInterval = time.Second
+
+// run builds the server, serves until ctx is cancelled, then shuts down gracefully
+func run(ctx context.Context, cfg pingme.Config) error {
+	server := newServer(cfg)
+	if api, ok := server.Handler.(*pingme.Server); ok {
+		logRoutes(api.Routes())
+		api.SetReloadOverrides(reapplyFlags)
 	}
 
-	respondJSON(w, http.StatusOK, Response{
-		Success: true,
-		Message: "Greeting retrieved successfully",
-		Data:    data,
-	})
+	listener, err := listenConfig(cfg).Listen(ctx, "tcp", server.Addr)
+	if err != nil {
+		return err
+	}
+	if api, ok := server.Handler.(*pingme.Server); ok && cfg.EnableGRPC {
+		stopGRPC, err := startGRPC(ctx, cfg, api)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		defer stopGRPC()
+	}
+	return serve(ctx, server, listener, cfg.ShutdownTimeout)
 }
 
-// healthHandler handles GET requests to the /healthz endpoint
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-	// Only allow GET requests
-	if r.Method != http.MethodGet {
-		respondJSON(w, http.StatusMethodNotAllowed, Response{
-			Success: false,
-			Error:   "Method not allowed. Use GET.",
-		})
-		return
+// startGRPC serves api's gRPC echo service on GRPC_PORT and returns a
+// function that stops it, waiting up to SHUTDOWN_TIMEOUT for in-flight calls
+func startGRPC(ctx context.Context, cfg pingme.Config, api *pingme.Server) (func(), error) {
+	listener, err := listenConfig(cfg).Listen(ctx, "tcp", net.JoinHostPort(cfg.Bind, cfg.GRPCPort))
+	if err != nil {
+		return nil, err
 	}
+	server := api.NewGRPCServer()
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		if err := server.Serve(listener); err != nil {
+			slog.Error("gRPC server failed", "error", err)
+		}
+	}()
+	slog.Info("gRPC server listening", "bind", cfg.Bind, "port", cfg.GRPCPort)
 
-	// Return health status
-	data := HealthData{
-		Status: "healthy",
-		Time:   time.Now().UTC(),
+	return func() {
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(cfg.ShutdownTimeout):
+			slog.Warn("gRPC shutdown timed out; cancelling in-flight calls")
+			server.Stop()
+		}
+		<-served
+	}, nil
+}
+
+// serve runs server on listener until ctx is cancelled, then waits up to
+// shutdownTimeout for connections to drain, logging progress as it goes
+func serve(ctx context.Context, server *http.Server, listener net.Listener, shutdownTimeout time.Duration) error {
+	api, _ := server.Handler.(*pingme.Server)
+	if api != nil {
+		go reloadOnHangup(ctx, api)
 	}
 
-	respondJSON(w, http.StatusOK, Response{
-		Success: true,
-		Message: "Service is healthy",
-		Data:    data,
-	})
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	// Stop accepting connections and let in-flight requests finish
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	drained := make(chan struct{})
+	logged := make(chan struct{})
+	go func() {
+		defer close(logged)
+		if api != nil {
+			logDrain(drained, api)
+		}
+	}()
+	err := server.Shutdown(shutdownCtx)
+	close(drained)
+	<-logged
+	if errors.Is(err, context.DeadlineExceeded) && api != nil {
+		slog.Warn("Shutdown timed out; force-closing connections", "open_connections", api.OpenConnections())
+		server.Close()
+	}
+	if api != nil {
+		// Hooks get a fresh budget: a slow drain may have used up shutdownCtx
+		hookCtx, cancelHooks := context.WithTimeout(context.Background(), shutdownTimeout)
+		if err := api.Shutdown(hookCtx); err != nil {
+			slog.Error("Shutdown hooks failed", "error", err)
+		}
+		cancelHooks()
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
-// echoHandler handles POST requests to the /echo endpoint
-func echoHandler(w http.ResponseWriter, r *http.Request) {
-	// Only allow POST requests
-	if r.Method != http.MethodPost {
-		respondJSON(w, http.StatusMethodNotAllowed, Response{
-			Success: false,
-			Error:   "Method not allowed. Use POST.",
-		})
-		return
-	}
-
-	// Verify Content-Type is application/json
-	contentType := r.Header.Get("Content-Type")
-	if contentType != "application/json" {
-		respondJSON(w, http.StatusUnsupportedMediaType, Response{
-			Success: false,
-			Error:   "Content-Type must be application/json",
-		})
-		return
-	}
-
-	// Decode JSON request body with strict validation
-	var req EchoRequest
-	decoder := json.NewDecoder(r.Body)
-	decoder.DisallowUnknownFields() // Reject unexpected fields
-
-	if err := decoder.Decode(&req); err != nil {
-		respondJSON(w, http.StatusBadRequest, Response{
-			Success: false,
-			Error:   fmt.Sprintf("Invalid JSON: %v", err),
-		})
-		return
-	}
-
-	// Validate that message is not empty
-	if req.Message == "" {
-		respondJSON(w, http.StatusBadRequest, Response{
-			Success: false,
-			Error:   "Message field cannot be empty",
-		})
-		return
-	}
-
-	// Create echo response
-	data := EchoData{
-		Original:  req.Message,
-		Echoed:    fmt.Sprintf("Echo: %s", req.Message),
-		Length:    len(req.Message),
-		Timestamp: time.Now().UTC(),
-	}
-
-	respondJSON(w, http.StatusOK, Response{
-		Success: true,
-		Message: "Echo processed successfully",
-		Data:    data,
-	})
+// logDrain reports the connections still open every drainLogInterval until drained is closed
+func logDrain(drained <-chan struct{}, api *pingme.Server) {
+	ticker := time.NewTicker(drainLogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-drained:
+			return
+		case <-ticker.C:
+			slog.Info("Waiting for connections to drain", "open_connections", api.OpenConnections())
+		}
+	}
 }
 
-// newServer creates and configures the HTTP server - extracted for testability
-func newServer(port string) *http.Server {
-	mux := http.NewServeMux()
-	mux.HandleFunc("/", greetingHandler)
-	mux.HandleFunc("/healthz", healthHandler)
-	mux.HandleFunc("/echo", echoHandler)
-
-	return &http.Server{
-		Addr:         ":" + port,
-		Handler:      mux,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  60 * time.Second,
+// reloadOnHangup re-reads the environment into api whenever the process
+// receives SIGHUP, first reopening LOG_FILE so rotated logs start afresh
+func reloadOnHangup(ctx context.Context, api *pingme.Server) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			if logFile != nil {
+				if err := logFile.Reopen(); err != nil {
+					slog.Error("Failed to reopen log file", "error", err)
+				}
+			}
+			// A rejected reload is logged and the running settings are kept
+			api.ReloadFromEnv()
+		}
 	}
 }
 
-// getPort returns the port from environment variable or default
-func getPort() string {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+// exitCode maps an error returned by run to the process exit code
+func exitCode(err error) int {
+	if errors.Is(err, syscall.EADDRINUSE) {
+		return exitBindError
 	}
-	return port
+	return exitRuntimeError
 }
 
 func main() {
-	port := getPort()
-	server := newServer(port)
+	os.Exit(execute(os.Args[1:]))
+}
+
+// execute runs the server with command-line args and returns the process
+// exit code. It returns rather than exiting so deferred cleanup, such as
+// flushing and closing LOG_FILE, runs before main calls os.Exit.
+func execute(args []string) int {
+	cfg := pingme.LoadConfig()
+	cfg.Port = getPort()
+	cfg, err := parseFlags(args, cfg)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		return exitUsageError
+	}
+	flagArgs = args
+	output, file, err := openLogOutput(cfg.LogFile)
+	slog.SetDefault(pingme.NewLogger(output, cfg))
+	if err != nil {
+		slog.Warn("Failed to open log file; logging to stderr", "path", cfg.LogFile, "error", err)
+	}
+	if file != nil {
+		logFile = file
+		defer file.Close()
+	}
+	if err := cfg.Validate(); err != nil {
+		var cfgErr *pingme.ConfigError
+		if errors.As(err, &cfgErr) {
+			for _, problem := range cfgErr.Problems {
+				slog.Error("Invalid configuration", "problem", problem)
+			}
+		} else {
+			slog.Error("Invalid configuration", "error", err)
+		}
+		return exitUsageError
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Start server
-	log.Printf("PingMe API starting on port %s...", port)
-	log.Printf("Endpoints available:")
-	log.Printf("  GET  / - Greeting endpoint")
-	log.Printf("  GET  /healthz - Health check endpoint")
-	log.Printf("  POST /echo - Echo endpoint")
+	slog.Info("PingMe API starting", "bind", cfg.Bind, "port", cfg.Port)
 
-	if err := server.ListenAndServe(); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	if err := run(ctx, cfg); err != nil {
+		if errors.Is(err, syscall.EADDRINUSE) {
+			slog.Error("Port is already in use. Stop the other process or set PORT (or GRPC_PORT) to a free port.", "port", cfg.Port, "grpc_port", cfg.GRPCPort)
+		} else {
+			slog.Error("Server failed", "error", err)
+		}
+		return exitCode(err)
 	}
+	slog.Info("PingMe API stopped")
+	return 0
 }
@@ -2,502 +2,493 @@ package main
 
 import (
 	"bytes"
-	"encoding/json"
-	"fmt"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
-)
-
-// TestGreetingHandler tests the GET / endpoint
-func TestGreetingHandler(t *testing.T) {
-	req := httptest.NewRequest(http.MethodGet, "/", nil)
-	w := httptest.NewRecorder()
-
-	greetingHandler(w, req)
-
-	res := w.Result()
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		t.Errorf("expected status 200, got %d", res.StatusCode)
-	}
 
-	contentType := res.Header.Get("Content-Type")
-	if contentType != "application/json" {
-		t.Errorf("expected Content-Type application/json, got %s", contentType)
-	}
+	pingmev1 "github.com/Caleb125-source/pingme-api/gen/pingme/v1"
+	"github.com/Caleb125-source/pingme-api/pingme"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
 
-	var response Response
-	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
-		t.Fatalf("failed to decode response: %v", err)
-	}
+// TestNewServer tests that newServer creates a properly configured server
+func TestNewServer(t *testing.T) {
+	cfg := pingme.DefaultConfig()
+	cfg.Port = "9090"
+	server := newServer(cfg)
 
-	if !response.Success {
-		t.Error("expected success to be true")
+	if server == nil {
+		t.Fatal("expected server to be non-nil")
 	}
 
-	if response.Message == "" {
-		t.Error("expected non-empty message")
+	if server.Addr != ":9090" {
+		t.Errorf("expected addr :9090, got %s", server.Addr)
 	}
 
-	if response.Data == nil {
-		t.Fatal("expected data field to be present")
+	if server.ReadTimeout != 10*time.Second {
+		t.Errorf("expected ReadTimeout 10s, got %v", server.ReadTimeout)
 	}
 
-	dataMap, ok := response.Data.(map[string]interface{})
-	if !ok {
-		t.Fatal("expected data to be a map")
+	if server.ReadHeaderTimeout != 5*time.Second {
+		t.Errorf("expected ReadHeaderTimeout 5s, got %v", server.ReadHeaderTimeout)
 	}
 
-	if dataMap["greeting"] == nil {
-		t.Error("expected 'greeting' field in data")
+	if server.WriteTimeout != 10*time.Second {
+		t.Errorf("expected WriteTimeout 10s, got %v", server.WriteTimeout)
 	}
 
-	if dataMap["timestamp"] == nil {
-		t.Error("expected 'timestamp' field in data")
+	if server.IdleTimeout != 60*time.Second {
+		t.Errorf("expected IdleTimeout 60s, got %v", server.IdleTimeout)
 	}
 
-	if timestamp, ok := dataMap["timestamp"].(string); ok {
-		if _, err := time.Parse(time.RFC3339, timestamp); err != nil {
-			t.Errorf("invalid timestamp format: %v", err)
-		}
+	if server.Handler == nil {
+		t.Error("expected server handler to be set")
 	}
 }
 
-// TestGreetingHandlerWrongMethod tests wrong HTTP method on greeting endpoint
-func TestGreetingHandlerWrongMethod(t *testing.T) {
-	methods := []string{http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch}
-
-	for _, method := range methods {
-		t.Run(method, func(t *testing.T) {
-			req := httptest.NewRequest(method, "/", nil)
-			w := httptest.NewRecorder()
-
-			greetingHandler(w, req)
-
-			res := w.Result()
-			defer res.Body.Close()
-
-			if res.StatusCode != http.StatusMethodNotAllowed {
-				t.Errorf("expected status 405 for method %s, got %d", method, res.StatusCode)
-			}
-
-			var response Response
-			if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
-				t.Fatalf("failed to decode response: %v", err)
-			}
-
-			if response.Success {
-				t.Error("expected success to be false for wrong method")
-			}
-
-			if response.Error == "" {
-				t.Error("expected error message for wrong method")
-			}
-		})
+// TestNewServerConnState tests that the connection state hook is always wired
+func TestNewServerConnState(t *testing.T) {
+	if newServer(pingme.DefaultConfig()).ConnState == nil {
+		t.Error("expected ConnState hook to be set")
 	}
 }
 
-// TestHealthHandler tests the GET /healthz endpoint
-func TestHealthHandler(t *testing.T) {
-	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
-	w := httptest.NewRecorder()
-
-	healthHandler(w, req)
-
-	res := w.Result()
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		t.Errorf("expected status 200, got %d", res.StatusCode)
-	}
-
-	var response Response
-	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
-		t.Fatalf("failed to decode response: %v", err)
-	}
+// TestNewServerKeepAlive tests that DISABLE_KEEPALIVE makes the server close each connection after one response
+func TestNewServerKeepAlive(t *testing.T) {
+	for _, disabled := range []bool{false, true} {
+		cfg := pingme.DefaultConfig()
+		cfg.DisableKeepAlive = disabled
+		server := newServer(cfg)
 
-	if !response.Success {
-		t.Error("expected success to be true")
-	}
-
-	if response.Data == nil {
-		t.Fatal("expected data field to be present")
-	}
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		go server.Serve(listener)
 
-	dataMap, ok := response.Data.(map[string]interface{})
-	if !ok {
-		t.Fatal("expected data to be a map")
-	}
+		res, err := http.Get("http://" + listener.Addr().String() + "/healthz")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		res.Body.Close()
+		server.Close()
 
-	status, ok := dataMap["status"].(string)
-	if !ok || status != "healthy" {
-		t.Errorf("expected status 'healthy', got %v", dataMap["status"])
+		if res.Close != disabled {
+			t.Errorf("DisableKeepAlive=%v: expected Connection: close %v, got %v", disabled, disabled, res.Close)
+		}
 	}
 }
 
-// TestHealthHandlerWrongMethod tests wrong HTTP method on health endpoint
-func TestHealthHandlerWrongMethod(t *testing.T) {
-	req := httptest.NewRequest(http.MethodPost, "/healthz", nil)
-	w := httptest.NewRecorder()
-
-	healthHandler(w, req)
-
-	res := w.Result()
-	defer res.Body.Close()
+// TestNewServerRoutes tests that newServer registers all routes correctly
+func TestNewServerRoutes(t *testing.T) {
+	server := newServer(pingme.DefaultConfig())
+	ts := httptest.NewServer(server.Handler)
+	defer ts.Close()
 
-	if res.StatusCode != http.StatusMethodNotAllowed {
-		t.Errorf("expected status 405, got %d", res.StatusCode)
+	// Test greeting route
+	res, err := http.Get(ts.URL + "/")
+	if err != nil {
+		t.Fatalf("failed to GET /: %v", err)
 	}
-}
-
-// TestEchoHandlerValidJSON tests POST /echo with valid JSON
-func TestEchoHandlerValidJSON(t *testing.T) {
-	payload := EchoRequest{Message: "Hello, World!"}
-	body, _ := json.Marshal(payload)
-
-	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBuffer(body))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-
-	echoHandler(w, req)
-
-	res := w.Result()
 	defer res.Body.Close()
-
 	if res.StatusCode != http.StatusOK {
-		t.Errorf("expected status 200, got %d", res.StatusCode)
-	}
-
-	var response Response
-	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
-		t.Fatalf("failed to decode response: %v", err)
+		t.Errorf("expected 200 from /, got %d", res.StatusCode)
 	}
 
-	if !response.Success {
-		t.Error("expected success to be true")
+	// Test health route
+	res, err = http.Get(ts.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("failed to GET /healthz: %v", err)
 	}
-
-	if response.Data == nil {
-		t.Fatal("expected data field to be present")
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 from /healthz, got %d", res.StatusCode)
 	}
 
-	dataMap, ok := response.Data.(map[string]interface{})
-	if !ok {
-		t.Fatal("expected data to be a map")
+	// Test echo route
+	payload := bytes.NewBufferString(`{"message": "test"}`)
+	res, err = http.Post(ts.URL+"/echo", "application/json", payload)
+	if err != nil {
+		t.Fatalf("failed to POST /echo: %v", err)
 	}
-
-	if dataMap["original"] != "Hello, World!" {
-		t.Errorf("expected original message 'Hello, World!', got %v", dataMap["original"])
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 from /echo, got %d", res.StatusCode)
 	}
+}
 
-	if dataMap["echoed"] != "Echo: Hello, World!" {
-		t.Errorf("expected echoed message 'Echo: Hello, World!', got %v", dataMap["echoed"])
+// TestGetPort tests the getPort function
+func TestGetPort(t *testing.T) {
+	// Test default port
+	os.Unsetenv("PORT")
+	port := getPort()
+	if port != "8080" {
+		t.Errorf("expected default port 8080, got %s", port)
 	}
 
-	if length, ok := dataMap["length"].(float64); !ok || int(length) != 13 {
-		t.Errorf("expected length 13, got %v", dataMap["length"])
+	// Test custom port from environment
+	os.Setenv("PORT", "3000")
+	defer os.Unsetenv("PORT")
+	port = getPort()
+	if port != "3000" {
+		t.Errorf("expected port 3000, got %s", port)
 	}
 }
 
-// TestEchoHandlerEmptyMessage tests validation for empty message
-func TestEchoHandlerEmptyMessage(t *testing.T) {
-	payload := EchoRequest{Message: ""}
-	body, _ := json.Marshal(payload)
-
-	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBuffer(body))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-
-	echoHandler(w, req)
-
-	res := w.Result()
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusBadRequest {
-		t.Errorf("expected status 400, got %d", res.StatusCode)
-	}
+// TestGetPortBuildDefault tests that an overridden defaultPort is used when PORT is unset
+func TestGetPortBuildDefault(t *testing.T) {
+	previous := defaultPort
+	defaultPort = "9000"
+	defer func() { defaultPort = previous }()
 
-	var response Response
-	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
-		t.Fatalf("failed to decode response: %v", err)
+	os.Unsetenv("PORT")
+	if port := getPort(); port != "9000" {
+		t.Errorf("expected build default port 9000, got %s", port)
 	}
 
-	if response.Success {
-		t.Error("expected success to be false for empty message")
+	// PORT still takes precedence over the build default
+	os.Setenv("PORT", "3000")
+	defer os.Unsetenv("PORT")
+	if port := getPort(); port != "3000" {
+		t.Errorf("expected port 3000, got %s", port)
 	}
+}
 
-	if response.Error == "" {
-		t.Error("expected error message for empty message")
+// TestParseFlags tests that flags override the environment, which overrides the defaults
+func TestParseFlags(t *testing.T) {
+	t.Setenv("PORT", "3000")
+	t.Setenv("LOG_LEVEL", "warn")
+
+	tests := []struct {
+		name         string
+		args         []string
+		wantPort     string
+		wantBind     string
+		wantLogLevel string
+	}{
+		{"env only", nil, "3000", "", "warn"},
+		{"flags override env", []string{"--port", "4000", "--log-level", "debug"}, "4000", "", "debug"},
+		{"bind", []string{"--bind", "127.0.0.1"}, "3000", "127.0.0.1", "warn"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := pingme.LoadConfig()
+			cfg.Port = getPort()
+
+			cfg, err := parseFlags(tt.args, cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.Port != tt.wantPort {
+				t.Errorf("expected port %s, got %s", tt.wantPort, cfg.Port)
+			}
+			if cfg.Bind != tt.wantBind {
+				t.Errorf("expected bind %q, got %q", tt.wantBind, cfg.Bind)
+			}
+			if cfg.LogLevel != tt.wantLogLevel {
+				t.Errorf("expected log level %s, got %s", tt.wantLogLevel, cfg.LogLevel)
+			}
+		})
 	}
 }
 
-// TestEchoHandlerInvalidJSON tests handling of malformed JSON
-func TestEchoHandlerInvalidJSON(t *testing.T) {
-	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString("{invalid json}"))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-
-	echoHandler(w, req)
-
-	res := w.Result()
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusBadRequest {
-		t.Errorf("expected status 400, got %d", res.StatusCode)
+// TestReapplyFlags tests that a reload keeps flags over the environment
+func TestReapplyFlags(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "")
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("RATE_LIMIT_RPS", "5")
+	flagArgs = []string{"--port", "4000", "--bind", "127.0.0.1", "--log-level", "debug"}
+	t.Cleanup(func() { flagArgs = nil })
+
+	cfg, _ := parseFlags(flagArgs, pingme.DefaultConfig())
+	api := pingme.NewServer(cfg)
+	api.SetReloadOverrides(reapplyFlags)
+	result, err := api.ReloadFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
 
-	var response Response
-	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
-		t.Fatalf("failed to decode response: %v", err)
+	if len(result.RestartRequired) != 0 {
+		t.Errorf("expected flag settings not to need a restart, got %v", result.RestartRequired)
 	}
-
-	if response.Success {
-		t.Error("expected success to be false for invalid JSON")
+	// LOG_LEVEL=warn loses to --log-level, so only the environment's rate limit changes
+	if len(result.Applied) != 1 || result.Applied[0] != "RATE_LIMIT_RPS" {
+		t.Errorf("expected applied [RATE_LIMIT_RPS], got %v", result.Applied)
 	}
 }
 
-// TestEchoHandlerUnknownFields tests strict JSON validation
-func TestEchoHandlerUnknownFields(t *testing.T) {
-	invalidPayload := `{"message": "test", "extra": "field"}`
-
-	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(invalidPayload))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-
-	echoHandler(w, req)
-
-	res := w.Result()
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusBadRequest {
-		t.Errorf("expected status 400, got %d", res.StatusCode)
-	}
-
-	var response Response
-	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
-		t.Fatalf("failed to decode response: %v", err)
-	}
-
-	if response.Success {
-		t.Error("expected success to be false for unknown fields")
+// TestParseFlagsUnknown tests that an unknown flag is rejected
+func TestParseFlagsUnknown(t *testing.T) {
+	if _, err := parseFlags([]string{"--nope"}, pingme.DefaultConfig()); err == nil {
+		t.Error("expected an error for an unknown flag")
 	}
 }
 
-// TestEchoHandlerWrongContentType tests Content-Type validation
-func TestEchoHandlerWrongContentType(t *testing.T) {
-	payload := EchoRequest{Message: "test"}
-	body, _ := json.Marshal(payload)
-
-	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBuffer(body))
-	req.Header.Set("Content-Type", "text/plain")
-	w := httptest.NewRecorder()
-
-	echoHandler(w, req)
-
-	res := w.Result()
-	defer res.Body.Close()
+// TestRunAddressInUse tests that run reports a bind failure when the port is taken
+func TestRunAddressInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("failed to pre-bind listener: %v", err)
+	}
+	defer listener.Close()
 
-	if res.StatusCode != http.StatusUnsupportedMediaType {
-		t.Errorf("expected status 415, got %d", res.StatusCode)
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to parse listener address: %v", err)
 	}
 
-	var response Response
-	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
-		t.Fatalf("failed to decode response: %v", err)
+	cfg := pingme.DefaultConfig()
+	cfg.Port = port
+	err = run(context.Background(), cfg)
+	if !errors.Is(err, syscall.EADDRINUSE) {
+		t.Fatalf("expected EADDRINUSE, got %v", err)
 	}
 
-	if response.Success {
-		t.Error("expected success to be false for wrong Content-Type")
+	if code := exitCode(err); code != exitBindError {
+		t.Errorf("expected exit code %d, got %d", exitBindError, code)
 	}
 }
 
-// TestEchoHandlerWrongMethod tests wrong HTTP method
-func TestEchoHandlerWrongMethod(t *testing.T) {
-	methods := []string{http.MethodGet, http.MethodPut, http.MethodDelete, http.MethodPatch}
-
-	for _, method := range methods {
-		t.Run(method, func(t *testing.T) {
-			req := httptest.NewRequest(method, "/echo", nil)
-			w := httptest.NewRecorder()
-
-			echoHandler(w, req)
-
-			res := w.Result()
-			defer res.Body.Close()
-
-			if res.StatusCode != http.StatusMethodNotAllowed {
-				t.Errorf("expected status 405 for method %s, got %d", method, res.StatusCode)
-			}
-		})
+// TestExitCode tests that non-bind errors map to the runtime exit code
+func TestExitCode(t *testing.T) {
+	if code := exitCode(errors.New("boom")); code != exitRuntimeError {
+		t.Errorf("expected exit code %d, got %d", exitRuntimeError, code)
 	}
 }
 
-// TestEchoHandlerEmptyBody tests handling of empty request body
-func TestEchoHandlerEmptyBody(t *testing.T) {
-	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBuffer([]byte{}))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
+// TestRunShutdown tests that run returns cleanly once its context is cancelled
+func TestRunShutdown(t *testing.T) {
+	cfg := pingme.DefaultConfig()
+	cfg.Port = "0"
 
-	echoHandler(w, req)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, cfg)
+	}()
 
-	res := w.Result()
-	defer res.Body.Close()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
 
-	if res.StatusCode != http.StatusBadRequest {
-		t.Errorf("expected status 400, got %d", res.StatusCode)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not return after context cancellation")
 	}
 }
 
-// TestRespondJSON tests the respondJSON helper function
-func TestRespondJSON(t *testing.T) {
-	w := httptest.NewRecorder()
-	response := Response{
-		Success: true,
-		Message: "Test message",
-		Data:    map[string]string{"key": "value"},
+// TestRunGRPC tests that run serves the echo RPC on GRPC_PORT when
+// ENABLE_GRPC is set and stops it on shutdown
+func TestRunGRPC(t *testing.T) {
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
 	}
+	_, grpcPort, _ := net.SplitHostPort(probe.Addr().String())
+	probe.Close()
 
-	respondJSON(w, http.StatusOK, response)
+	cfg := pingme.DefaultConfig()
+	cfg.Port = "0"
+	cfg.Bind = "127.0.0.1"
+	cfg.EnableGRPC = true
+	cfg.GRPCPort = grpcPort
 
-	res := w.Result()
-	defer res.Body.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, cfg)
+	}()
 
-	if res.StatusCode != http.StatusOK {
-		t.Errorf("expected status 200, got %d", res.StatusCode)
+	conn, err := grpc.NewClient(net.JoinHostPort(cfg.Bind, grpcPort), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
 	}
-
-	contentType := res.Header.Get("Content-Type")
-	if contentType != "application/json" {
-		t.Errorf("expected Content-Type application/json, got %s", contentType)
+	defer conn.Close()
+	callCtx, cancelCall := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCall()
+	res, err := pingmev1.NewEchoServiceClient(conn).Echo(callCtx, &pingmev1.EchoRequest{Message: "hi"}, grpc.WaitForReady(true))
+	if err != nil {
+		t.Fatalf("Echo: %v", err)
 	}
-
-	var decoded Response
-	if err := json.NewDecoder(res.Body).Decode(&decoded); err != nil {
-		t.Fatalf("failed to decode response: %v", err)
+	if res.GetEchoed() != "Echo: hi" {
+		t.Errorf("expected echoed %q, got %q", "Echo: hi", res.GetEchoed())
 	}
 
-	if decoded.Success != response.Success {
-		t.Errorf("expected success %v, got %v", response.Success, decoded.Success)
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not return after context cancellation")
 	}
 }
 
-// brokenWriter simulates a ResponseWriter that fails on Write
-type brokenWriter struct {
-	httptest.ResponseRecorder
-}
+// TestServeDrainLogging tests that shutdown reports open connections while a slow request finishes
+func TestServeDrainLogging(t *testing.T) {
+	var logs bytes.Buffer
+	previousLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer slog.SetDefault(previousLogger)
 
-func (b *brokenWriter) Write(p []byte) (int, error) {
-	return 0, fmt.Errorf("simulated write error")
-}
+	previousInterval := drainLogInterval
+	drainLogInterval = 10 * time.Millisecond
+	defer func() { drainLogInterval = previousInterval }()
 
-// TestRespondJSONEncodingError tests the error path in respondJSON
-func TestRespondJSONEncodingError(t *testing.T) {
-	w := &brokenWriter{*httptest.NewRecorder()}
-	response := Response{
-		Success: true,
-		Message: "test",
-	}
-	// Should not panic even when write fails
-	respondJSON(w, http.StatusOK, response)
-}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := newServer(pingme.DefaultConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(ctx, server, listener, 5*time.Second)
+	}()
+
+	status := make(chan int, 1)
+	go func() {
+		body := bytes.NewBufferString(`{"message": "slow", "delay_ms": 300}`)
+		res, err := http.Post("http://"+listener.Addr().String()+"/echo", "application/json", body)
+		if err != nil {
+			status <- 0
+			return
+		}
+		res.Body.Close()
+		status <- res.StatusCode
+	}()
 
-// TestNewServer tests that newServer creates a properly configured server
-func TestNewServer(t *testing.T) {
-	server := newServer("9090")
+	time.Sleep(100 * time.Millisecond)
+	cancel()
 
-	if server == nil {
-		t.Fatal("expected server to be non-nil")
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve did not return after the slow request finished")
 	}
 
-	if server.Addr != ":9090" {
-		t.Errorf("expected addr :9090, got %s", server.Addr)
+	if code := <-status; code != http.StatusOK {
+		t.Errorf("expected the in-flight request to complete with 200, got %d", code)
 	}
-
-	if server.ReadTimeout != 10*time.Second {
-		t.Errorf("expected ReadTimeout 10s, got %v", server.ReadTimeout)
+	if !bytes.Contains(logs.Bytes(), []byte("Waiting for connections to drain")) {
+		t.Errorf("expected drain progress to be logged, got %q", logs.String())
 	}
+}
 
-	if server.WriteTimeout != 10*time.Second {
-		t.Errorf("expected WriteTimeout 10s, got %v", server.WriteTimeout)
+// TestServeRunsShutdownHooks tests that graceful shutdown runs registered hooks exactly once
+func TestServeRunsShutdownHooks(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
 	}
+	server := newServer(pingme.DefaultConfig())
+	var calls atomic.Int32
+	server.Handler.(*pingme.Server).OnShutdown(func(context.Context) error {
+		calls.Add(1)
+		return nil
+	})
 
-	if server.IdleTimeout != 60*time.Second {
-		t.Errorf("expected IdleTimeout 60s, got %v", server.IdleTimeout)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := serve(ctx, server, listener, time.Second); err != nil {
+		t.Fatalf("expected clean shutdown, got %v", err)
 	}
-
-	if server.Handler == nil {
-		t.Error("expected server handler to be set")
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected the hook to run once, ran %d times", got)
 	}
 }
 
-// TestNewServerRoutes tests that newServer registers all routes correctly
-func TestNewServerRoutes(t *testing.T) {
-	server := newServer("8080")
-	ts := httptest.NewServer(server.Handler)
-	defer ts.Close()
-
-	// Test greeting route
-	res, err := http.Get(ts.URL + "/")
+// TestServeShutdownHooksAfterSlowDrain tests that hooks get a live context after the drain times out
+func TestServeShutdownHooksAfterSlowDrain(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
-		t.Fatalf("failed to GET /: %v", err)
-	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		t.Errorf("expected 200 from /, got %d", res.StatusCode)
+		t.Fatalf("failed to listen: %v", err)
 	}
+	server := newServer(pingme.DefaultConfig())
+	hookErr := make(chan error, 1)
+	server.Handler.(*pingme.Server).OnShutdown(func(ctx context.Context) error {
+		hookErr <- ctx.Err()
+		return nil
+	})
 
-	// Test health route
-	res, err = http.Get(ts.URL + "/healthz")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- serve(ctx, server, listener, 50*time.Millisecond) }()
+
+	// A half-sent request keeps the connection active, so the drain times out
+	conn, err := net.Dial("tcp", listener.Addr().String())
 	if err != nil {
-		t.Fatalf("failed to GET /healthz: %v", err)
+		t.Fatalf("failed to dial: %v", err)
 	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		t.Errorf("expected 200 from /healthz, got %d", res.StatusCode)
+	defer conn.Close()
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\n")); err != nil {
+		t.Fatalf("failed to write: %v", err)
 	}
+	time.Sleep(50 * time.Millisecond)
+	cancel()
 
-	// Test echo route
-	payload := bytes.NewBufferString(`{"message": "test"}`)
-	res, err = http.Post(ts.URL+"/echo", "application/json", payload)
-	if err != nil {
-		t.Fatalf("failed to POST /echo: %v", err)
+	if err := <-done; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the drain to time out, got %v", err)
 	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		t.Errorf("expected 200 from /echo, got %d", res.StatusCode)
+	if err := <-hookErr; err != nil {
+		t.Errorf("expected the hook context to be live, got %v", err)
 	}
 }
 
-// TestGetPort tests the getPort function
-func TestGetPort(t *testing.T) {
-	// Test default port
-	os.Unsetenv("PORT")
-	port := getPort()
-	if port != "8080" {
-		t.Errorf("expected default port 8080, got %s", port)
+// TestOpenLogOutput tests the stdout and stderr names and the fallback when the file can't be opened
+func TestOpenLogOutput(t *testing.T) {
+	if w, file, err := openLogOutput("stdout"); w != os.Stdout || file != nil || err != nil {
+		t.Errorf("expected stdout, got %v, %v, %v", w, file, err)
 	}
-
-	// Test custom port from environment
-	os.Setenv("PORT", "3000")
-	defer os.Unsetenv("PORT")
-	port = getPort()
-	if port != "3000" {
-		t.Errorf("expected port 3000, got %s", port)
+	if w, file, err := openLogOutput(""); w != os.Stderr || file != nil || err != nil {
+		t.Errorf("expected stderr by default, got %v, %v, %v", w, file, err)
+	}
+	w, file, err := openLogOutput(t.TempDir() + "/missing/pingme.log")
+	if err == nil || w != os.Stderr || file != nil {
+		t.Errorf("expected a stderr fallback with an error, got %v, %v, %v", w, file, err)
 	}
 }
 
-// BenchmarkEchoHandler benchmarks the echo endpoint performance
-func BenchmarkEchoHandler(b *testing.B) {
-	payload := `{"message": "benchmark test"}`
-	for i := 0; i < b.N; i++ {
-		req := httptest.NewRequest(http.MethodPost, "/echo",
-			bytes.NewBufferString(payload))
-		req.Header.Set("Content-Type", "application/json")
-		w := httptest.NewRecorder()
-		echoHandler(w, req)
+// TestExecuteClosesLogFile tests that an early exit still logs to LOG_FILE and closes it
+func TestExecuteClosesLogFile(t *testing.T) {
+	previous := slog.Default()
+	t.Cleanup(func() {
+		slog.SetDefault(previous)
+		logFile, flagArgs = nil, nil
+	})
+	path := t.TempDir() + "/pingme.log"
+	t.Setenv("LOG_FILE", path)
+	t.Setenv("PORT", "0")
+
+	if code := execute(nil); code != exitUsageError {
+		t.Fatalf("expected exit code %d, got %d", exitUsageError, code)
+	}
+	logs, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	if !bytes.Contains(logs, []byte("Invalid configuration")) {
+		t.Errorf("expected the validation failure in the log file, got %q", logs)
+	}
+	if _, err := logFile.Write([]byte("late")); err == nil {
+		t.Error("expected the log file to be closed")
 	}
 }
@@ -0,0 +1,53 @@
+package pingme
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+)
+
+// assetFiles holds the files served under /assets/
+//
+//go:embed assets
+var assetFiles embed.FS
+
+// assetETags maps each embedded file's URL path to a strong ETag of its
+// contents. Embedded files have no modification time, so without these
+// http.FileServer could not answer If-None-Match or If-Range.
+var assetETags = hashAssets(assetFiles)
+
+// hashAssets computes a quoted SHA-256 ETag for every file in fsys
+func hashAssets(fsys fs.FS) map[string]string {
+	etags := make(map[string]string)
+	_ = fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(content)
+		etags["/"+path] = `"` + hex.EncodeToString(sum[:16]) + `"`
+		return nil
+	})
+	return etags
+}
+
+// assetsHandler handles GET /assets/ requests. http.FileServer provides
+// Range, If-Range and If-None-Match handling once the ETag is set; paths
+// that aren't embedded files, including directories, get the JSON 404.
+func (s *Server) assetsHandler() http.HandlerFunc {
+	files := http.FileServerFS(assetFiles)
+	return func(w http.ResponseWriter, r *http.Request) {
+		etag, ok := assetETags[r.URL.Path]
+		if !ok {
+			s.notFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		files.ServeHTTP(w, r)
+	}
+}
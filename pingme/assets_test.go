@@ -0,0 +1,111 @@
+package pingme
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// readAsset returns an embedded asset's contents
+func readAsset(t *testing.T, name string) []byte {
+	t.Helper()
+	content, err := assetFiles.ReadFile(name)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", name, err)
+	}
+	return content
+}
+
+// TestAssetsRange tests that a byte range returns 206 with that slice of the file
+func TestAssetsRange(t *testing.T) {
+	content := readAsset(t, "assets/openapi.yaml")
+
+	req := httptest.NewRequest(http.MethodGet, "/assets/openapi.yaml", nil)
+	req.Header.Set("Range", "bytes=10-29")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("expected status 206, got %d", w.Code)
+	}
+	if got := w.Body.String(); got != string(content[10:30]) {
+		t.Errorf("expected bytes 10-29 %q, got %q", content[10:30], got)
+	}
+	if got, want := w.Header().Get("Content-Range"), "bytes 10-29/"+strconv.Itoa(len(content)); got != want {
+		t.Errorf("expected Content-Range %q, got %q", want, got)
+	}
+}
+
+// TestAssetsRangeNotCompressed tests that gzip leaves partial responses alone
+func TestAssetsRangeNotCompressed(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/assets/openapi.yaml", nil)
+	req.Header.Set("Range", "bytes=0-9")
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("expected status 206, got %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("expected no Content-Encoding on a range, got %q", got)
+	}
+	if w.Body.Len() != 10 {
+		t.Errorf("expected 10 bytes, got %d", w.Body.Len())
+	}
+}
+
+// TestAssetsConditional tests ETag revalidation and If-Range
+func TestAssetsConditional(t *testing.T) {
+	etag := assetETags["/assets/openapi.yaml"]
+	if etag == "" {
+		t.Fatal("expected an ETag for openapi.yaml")
+	}
+
+	tests := []struct {
+		name     string
+		header   string
+		value    string
+		expected int
+	}{
+		{"matching If-None-Match", "If-None-Match", etag, http.StatusNotModified},
+		{"stale If-None-Match", "If-None-Match", `"stale"`, http.StatusPartialContent},
+		{"matching If-Range", "If-Range", etag, http.StatusPartialContent},
+		{"stale If-Range", "If-Range", `"stale"`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/assets/openapi.yaml", nil)
+			req.Header.Set("Range", "bytes=0-9")
+			req.Header.Set(tt.header, tt.value)
+			w := httptest.NewRecorder()
+
+			newTestServer().ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("expected status %d, got %d", tt.expected, w.Code)
+			}
+			if got := w.Header().Get("ETag"); got != etag {
+				t.Errorf("expected ETag %s, got %q", etag, got)
+			}
+		})
+	}
+}
+
+// TestAssetsNotFound tests that missing files and directories get the JSON 404
+func TestAssetsNotFound(t *testing.T) {
+	for _, path := range []string{"/assets/missing.txt", "/assets/"} {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			w := httptest.NewRecorder()
+
+			newTestServer().ServeHTTP(w, req)
+
+			assertError(t, w, http.StatusNotFound, codeNotFound)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package pingme
+
+import (
+	"io"
+	"net/http"
+)
+
+// maxLoggedBodyBytes caps how much of a request body debug logging keeps
+const maxLoggedBodyBytes = 1024
+
+// credentialHeaders are request headers whose presence disables body logging
+var credentialHeaders = []string{"Authorization", "X-API-Key", "Cookie"}
+
+// cappedBuffer keeps the first limit bytes written to it and notes whether more arrived
+type cappedBuffer struct {
+	limit     int
+	data      []byte
+	truncated bool
+}
+
+// Write records up to the remaining capacity and always reports a full write
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - len(b.data); room > 0 {
+		if len(p) > room {
+			b.data = append(b.data, p[:room]...)
+			b.truncated = true
+		} else {
+			b.data = append(b.data, p...)
+		}
+	} else if len(p) > 0 {
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+// hasCredentials reports whether the request carries any auth headers
+func hasCredentials(r *http.Request) bool {
+	for _, header := range credentialHeaders {
+		if r.Header.Get(header) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// teeBodyForLogging returns a reader over r.Body that copies what is read into a
+// capped buffer, and a function that logs the captured body. Both are no-ops
+// unless body logging is enabled and the request carries no credentials.
+func (s *Server) teeBodyForLogging(r *http.Request) (io.Reader, func()) {
+	if !s.config().DebugLogBodies || hasCredentials(r) {
+		return r.Body, func() {}
+	}
+
+	captured := &cappedBuffer{limit: maxLoggedBodyBytes}
+	return io.TeeReader(r.Body, captured), func() {
+		s.logger.Debug("Echo request body",
+			"request_id", requestIDFromContext(r.Context()),
+			"body", string(captured.data),
+			"truncated", captured.truncated,
+		)
+	}
+}
@@ -0,0 +1,103 @@
+package pingme
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// captureLog points the server's logger at a buffer and returns it
+func captureLog(server *Server) *bytes.Buffer {
+	var buf bytes.Buffer
+	server.logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	return &buf
+}
+
+// TestDebugLogBodiesTruncated tests that enabled body logging records a truncated body with the request ID
+func TestDebugLogBodiesTruncated(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DebugLogBodies = true
+	server := NewServer(cfg)
+	logs := captureLog(server)
+
+	message := strings.Repeat("x", 2000)
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"message": "`+message+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(requestIDHeader, "body-log-test")
+	w := httptest.NewRecorder()
+
+	server.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected decoding to still succeed, got status %d", w.Code)
+	}
+
+	output := logs.String()
+	if !strings.Contains(output, "request_id=body-log-test") {
+		t.Errorf("expected request ID in log, got %q", output)
+	}
+	if !strings.Contains(output, "truncated=true") {
+		t.Errorf("expected truncation marker in log, got %q", output)
+	}
+	if strings.Contains(output, message) {
+		t.Error("expected logged body to be truncated to 1KB")
+	}
+	if !strings.Contains(output, strings.Repeat("x", 100)) {
+		t.Errorf("expected body prefix in log, got %q", output)
+	}
+}
+
+// TestDebugLogBodiesSkipsCredentials tests that bodies are never logged alongside auth headers
+func TestDebugLogBodiesSkipsCredentials(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DebugLogBodies = true
+	server := NewServer(cfg)
+	logs := captureLog(server)
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"message": "secret"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+
+	server.ServeHTTP(w, req)
+
+	if strings.Contains(logs.String(), "secret") {
+		t.Errorf("expected no body logging with credentials present, got %q", logs.String())
+	}
+}
+
+// TestDebugLogBodiesDisabled tests that bodies are not logged by default
+func TestDebugLogBodiesDisabled(t *testing.T) {
+	server := newTestServer()
+	logs := captureLog(server)
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"message": "quiet"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	server.ServeHTTP(httptest.NewRecorder(), req)
+
+	if strings.Contains(logs.String(), "quiet") {
+		t.Errorf("expected no body logging by default, got %q", logs.String())
+	}
+}
+
+// TestDebugLogBodiesAtDebugLevel tests that bodies stay out of info-level logs
+func TestDebugLogBodiesAtDebugLevel(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DebugLogBodies = true
+	server := NewServer(cfg)
+	var logs bytes.Buffer
+	server.logger = slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"message": "payload"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	server.ServeHTTP(httptest.NewRecorder(), req)
+
+	if strings.Contains(logs.String(), "payload") {
+		t.Errorf("expected no body logging at info level, got %q", logs.String())
+	}
+}
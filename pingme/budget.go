@@ -0,0 +1,36 @@
+package pingme
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// callWithTimeout runs fn with its context bounded by d and returns fn's
+// error, or a 504 gateway_timeout error once d has passed. A call still
+// running at that point is abandoned rather than waited on, so a dependency
+// that ignores its context can't hold up the handler. d <= 0 runs fn
+// without a deadline.
+func callWithTimeout(ctx context.Context, d time.Duration, fn func(context.Context) error) error {
+	if d <= 0 {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	// Buffered so an abandoned call can still send and exit
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return newAPIError(http.StatusGatewayTimeout, codeGatewayTimeout, "timed out after %s", d)
+		}
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,34 @@
+package pingme
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestCallWithTimeout tests that slow work becomes a 504 while fast work keeps its own result
+func TestCallWithTimeout(t *testing.T) {
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	slow := func(context.Context) error {
+		<-release
+		return nil
+	}
+
+	start := time.Now()
+	err := callWithTimeout(context.Background(), 20*time.Millisecond, slow)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the call to be abandoned at its deadline, took %v", elapsed)
+	}
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusGatewayTimeout || apiErr.Code != codeGatewayTimeout {
+		t.Errorf("expected a 504 gateway_timeout error, got %v", err)
+	}
+
+	down := errors.New("connection refused")
+	if err := callWithTimeout(context.Background(), time.Second, func(context.Context) error { return down }); err != down {
+		t.Errorf("expected the function's own error, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package pingme
+
+import (
+	"net/http"
+	"strings"
+)
+
+// cacheControlEnv maps route paths to the environment variables overriding their Cache-Control
+var cacheControlEnv = map[string]string{
+	"/":        "GREETING_CACHE_CONTROL",
+	"/version": "VERSION_CACHE_CONTROL",
+	"/echo":    "ECHO_CACHE_CONTROL",
+}
+
+// defaultCacheControl is the conservative starting policy: short public
+// caching for responses that only change with a restart or reload, no-store
+// for echoes of client input, and no header for everything else
+func defaultCacheControl() map[string]string {
+	return map[string]string{
+		"/":            "public, max-age=60",
+		"/version":     "public, max-age=300",
+		"/echo":        "no-store",
+		"/echo/file":   "no-store",
+		"/echo/ndjson": "no-store",
+		"/echo/raw":    "no-store",
+	}
+}
+
+// withCacheControl sets the path's Config.CacheControl entry as the
+// Cache-Control header on successful responses. Errors are left without
+// one so a CDN doesn't hold on to a failure.
+func (s *Server) withCacheControl(path string, h http.Handler) http.Handler {
+	policy := s.cacheControl[strings.TrimSuffix(path, "{$}")]
+	if policy == "" {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := newResponseRecorder(w, nil)
+		rec.beforeHeader = func(header http.Header) {
+			if rec.status < http.StatusBadRequest && header.Get("Cache-Control") == "" {
+				header.Set("Cache-Control", policy)
+			}
+		}
+		h.ServeHTTP(rec, r)
+	})
+}
@@ -0,0 +1,50 @@
+package pingme
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestCacheControl tests the default per-endpoint Cache-Control policy
+func TestCacheControl(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		expected string
+	}{
+		{"echo", http.MethodPost, "/echo", `{"message": "hi"}`, "no-store"},
+		{"version", http.MethodGet, "/version", "", "public, max-age=300"},
+		{"greeting", http.MethodGet, "/", "", "public, max-age=60"},
+		{"unlisted", http.MethodGet, "/healthz", "", ""},
+		{"echo error", http.MethodPost, "/echo", `{"message": ""}`, ""},
+	}
+
+	server := newTestServer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Cache-Control"); got != tt.expected {
+				t.Errorf("expected Cache-Control %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestCacheControlEnv tests that an environment variable overrides one endpoint's policy
+func TestCacheControlEnv(t *testing.T) {
+	t.Setenv("VERSION_CACHE_CONTROL", "public, max-age=3600")
+	w := httptest.NewRecorder()
+	NewServer(LoadConfig()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=3600" {
+		t.Errorf("expected the overridden policy, got %q", got)
+	}
+}
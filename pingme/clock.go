@@ -0,0 +1,33 @@
+package pingme
+
+import (
+	"net/http"
+	"time"
+)
+
+// TimeData represents the data returned by the time endpoint. Every field
+// is derived from one reading of the clock, so they always agree.
+type TimeData struct {
+	RFC3339       string  `json:"rfc3339"`
+	Unix          int64   `json:"unix"`
+	UnixMillis    int64   `json:"unix_ms"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+}
+
+// timeHandler handles GET /time requests
+func (s *Server) timeHandler(w http.ResponseWriter, r *http.Request) {
+	now := s.now()
+	data := TimeData{
+		RFC3339:    now.UTC().Format(time.RFC3339Nano),
+		Unix:       now.Unix(),
+		UnixMillis: now.UnixMilli(),
+		// Sub uses the monotonic readings, so wall clock jumps don't skew uptime
+		UptimeSeconds: now.Sub(s.started).Seconds(),
+	}
+
+	s.respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Time retrieved successfully",
+		Data:    data,
+	})
+}
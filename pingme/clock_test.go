@@ -0,0 +1,83 @@
+package pingme
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestTimeHandler tests that every representation matches an injected clock
+func TestTimeHandler(t *testing.T) {
+	server := newTestServer()
+	fixed := time.Date(2024, 2, 15, 10, 30, 0, 250_000_000, time.UTC)
+	server.started = fixed.Add(-90 * time.Second)
+	server.now = func() time.Time { return fixed }
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/time", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var response struct {
+		Data TimeData `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	expected := TimeData{
+		RFC3339:       "2024-02-15T10:30:00.25Z",
+		Unix:          1707993000,
+		UnixMillis:    1707993000250,
+		UptimeSeconds: 90,
+	}
+	if response.Data != expected {
+		t.Errorf("expected %+v, got %+v", expected, response.Data)
+	}
+
+	parsed, err := time.Parse(time.RFC3339Nano, response.Data.RFC3339)
+	if err != nil {
+		t.Fatalf("rfc3339 does not parse: %v", err)
+	}
+	if parsed.Unix() != response.Data.Unix || parsed.UnixMilli() != response.Data.UnixMillis {
+		t.Errorf("rfc3339 %s disagrees with unix %d and unix_ms %d", response.Data.RFC3339, response.Data.Unix, response.Data.UnixMillis)
+	}
+}
+
+// TestTimestampsUseClock tests that greeting, echo and health timestamps come from the injected clock
+func TestTimestampsUseClock(t *testing.T) {
+	server := newTestServer()
+	fixed := time.Date(2024, 2, 15, 10, 30, 0, 0, time.UTC)
+	server.now = func() time.Time { return fixed }
+
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/", nil),
+		httptest.NewRequest(http.MethodGet, "/greet/morning", nil),
+		httptest.NewRequest(http.MethodGet, "/echo?message=hi", nil),
+		httptest.NewRequest(http.MethodGet, "/healthz", nil),
+	}
+	for _, req := range requests {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+
+		var response struct {
+			Data struct {
+				Timestamp time.Time `json:"timestamp"`
+				Time      time.Time `json:"time"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", req.URL, err)
+		}
+		got := response.Data.Timestamp
+		if got.IsZero() {
+			got = response.Data.Time
+		}
+		if !got.Equal(fixed) {
+			t.Errorf("%s: expected timestamp %v, got %v", req.URL, fixed, got)
+		}
+	}
+}
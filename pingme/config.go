@@ -0,0 +1,578 @@
+package pingme
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported values for Config.JSONCase
+const (
+	JSONCaseSnake = "snake"
+	JSONCaseCamel = "camel"
+)
+
+// Config holds the settings for the PingMe API and the HTTP server around it
+type Config struct {
+	// Bind is the interface address to listen on; empty listens on all interfaces
+	Bind string
+	Port string
+	// ReadTimeout bounds reading a whole request, headers and body
+	ReadTimeout time.Duration
+	// ReadHeaderTimeout bounds how long a client may take to send request headers
+	ReadHeaderTimeout time.Duration
+	// WriteTimeout bounds writing a response, counted from the end of the request headers
+	WriteTimeout time.Duration
+	// IdleTimeout is how long a kept-alive connection may wait for its next
+	// request; 0 falls back to ReadTimeout
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+	// DisableKeepAlive closes every connection after one response, for
+	// debugging connection reuse; IdleTimeout then never applies
+	DisableKeepAlive bool
+	// ReusePort sets SO_REUSEPORT on the listener so a new instance can bind
+	// the port before the old one exits; Linux and the BSDs only
+	ReusePort bool
+	// EnableGRPC serves the pingme.v1.EchoService gRPC API on GRPCPort
+	// alongside the HTTP server
+	EnableGRPC bool
+	GRPCPort   string
+
+	// HandlerTimeout is the deadline given to each request's context; 0 disables it
+	HandlerTimeout time.Duration
+	// RouteTimeouts overrides HandlerTimeout for individual route paths such as "/echo"
+	RouteTimeouts map[string]time.Duration
+	// CacheControl sets the Cache-Control header of successful responses per
+	// route path, such as "/version"; paths without an entry send none
+	CacheControl map[string]string
+	// SlowRequestThreshold is the duration past which a request is logged as slow; 0 disables it
+	SlowRequestThreshold time.Duration
+	// HealthTimeout bounds how long /healthz waits for its dependency checks
+	HealthTimeout time.Duration
+	// MetricsPushURL is where the /metrics instruments are pushed: udp://host:port
+	// for StatsD or an http(s) Pushgateway URL; empty disables pushing
+	MetricsPushURL string
+	// MetricsPushInterval is how often metrics are pushed to MetricsPushURL
+	MetricsPushInterval time.Duration
+	// WarmupDuration keeps /readyz not ready for this long after start; 0 disables it
+	WarmupDuration time.Duration
+
+	// LogLevel is the minimum level logged: debug, info, warn or error
+	LogLevel string
+	// LogFormat selects text or json log lines
+	LogFormat string
+	// LogFile is where logs are written: a file path, opened for appending and
+	// reopened on SIGHUP, or "stdout"; empty or "stderr" means stderr
+	LogFile string
+	// LogSampleRate is the fraction of successful requests given an access
+	// log line, from 0 to 1; errors and slow requests are always logged
+	LogSampleRate float64
+
+	// Greeting is the message returned by GET /
+	Greeting string
+	// EchoPrefix is prepended to the message in /echo responses without a
+	// mode; empty echoes the message unchanged
+	EchoPrefix string
+	// DisableGreeting drops the GET / route so / returns 404
+	DisableGreeting bool
+	// DisableEcho drops every /echo route so they return 404
+	DisableEcho bool
+	// StrictUTF8 rejects echo messages that aren't valid UTF-8 instead of
+	// echoing them with U+FFFD in place of the bad sequences
+	StrictUTF8 bool
+
+	// AdminAPIKey guards the /admin endpoints via the X-API-Key header; empty disables them
+	AdminAPIKey string
+
+	// JSONCase selects snake_case (default) or camelCase response keys
+	JSONCase string
+	// StrictEnvelope always includes the message, data and error keys, as
+	// empty strings or null, instead of omitting them when empty
+	StrictEnvelope bool
+	// CompactJSON drops the trailing newline after JSON response bodies;
+	// NDJSON lines keep theirs
+	CompactJSON bool
+	// PoolBuffers reuses echo request structs, response buffers and
+	// MessagePack encoders across requests to cut per-request allocations
+	PoolBuffers bool
+	// ResponseWrapper, when set, nests the whole envelope under this key,
+	// as in {"result": {...}}; JSONCase doesn't change the key itself
+	ResponseWrapper string
+
+	// MaxMessageLength is the longest /echo message accepted, counted in runes
+	// so multibyte text isn't penalized; 0 disables the check
+	MaxMessageLength int
+	// MaxBodyBytes caps request bodies; larger ones get 413. 0 disables the check
+	MaxBodyBytes int64
+	// MaxJSONDepth and MaxJSONTokens bound how deeply JSON bodies may nest and
+	// how many tokens they may hold, checked before decoding; 0 disables each
+	MaxJSONDepth  int
+	MaxJSONTokens int
+	// MaxFileBytes caps the file uploaded to /echo/file; 0 means no limit
+	// beyond MaxBodyBytes
+	MaxFileBytes int64
+	// MaxURLLength is the longest request URL accepted; 0 disables the check
+	MaxURLLength int
+	// MaxHeaderCount is the most header lines a request may send; 0 disables the check
+	MaxHeaderCount int
+
+	// PathPrefix mounts every route beneath it, e.g. "/pingme"; empty serves from the root
+	PathPrefix string
+
+	// DebugLogBodies logs raw /echo request bodies, truncated, for troubleshooting
+	DebugLogBodies bool
+
+	// TrustProxy honours X-Forwarded-For and X-Real-IP when resolving client IPs
+	TrustProxy bool
+	// TrustedProxies lists the CIDRs or IPs of proxies whose forwarded headers
+	// are honoured; when set, it replaces TrustProxy's trust-everyone behaviour
+	TrustedProxies []string
+
+	// RateLimitRPS is the sustained requests per second allowed per client; 0 disables rate limiting
+	RateLimitRPS float64
+	// RateLimitBurst is how many requests a client may make at once
+	RateLimitBurst int
+	// RateLimitMaxClients caps how many clients are tracked; 0 means unbounded
+	RateLimitMaxClients int
+
+	// LogConnState debug-logs each connection state change counted at /stats
+	LogConnState bool
+
+	// Gzip compresses compressible responses for clients that accept it
+	Gzip bool
+
+	// MaxConcurrent caps in-flight requests; 0 means unlimited
+	MaxConcurrent int
+
+	// EchoHistorySize is how many POST /echo results are kept for
+	// GET /echo/history/{id}; recorded echoes answer 201 Created. 0 disables it
+	EchoHistorySize int
+
+	// IdempotencyTTL is how long POST /echo replays the response for an
+	// Idempotency-Key; 0 disables replaying
+	IdempotencyTTL time.Duration
+	// IdempotencyMaxKeys caps how many keys are remembered at once
+	IdempotencyMaxKeys int
+
+	// CORSAllowedOrigins lists the origins allowed cross-origin access, or
+	// "*" for any; empty disables CORS
+	CORSAllowedOrigins []string
+	// CORSMaxAge is how many seconds browsers may cache a preflight response
+	CORSMaxAge int
+	// AllowCredentials lets browsers send cookies and auth headers
+	// cross-origin; it can't be combined with a "*" origin
+	AllowCredentials bool
+
+	// ResponseSigningKey, when set, signs /echo response bodies with
+	// HMAC-SHA256 in an X-Signature header
+	ResponseSigningKey string
+
+	// MaintenanceMode answers every route except health checks and /admin
+	// with 503; POST /admin/maintenance toggles it at runtime
+	MaintenanceMode bool
+
+	// DisabledMiddleware names middlewares to leave out of the chain, e.g. "gzip"
+	DisabledMiddleware []string
+
+	// envProblems lists environment values LoadConfig couldn't parse, which
+	// Validate reports ahead of its own checks
+	envProblems []string
+}
+
+// ConfigError lists every problem Validate found, so all of them can be
+// fixed in one go rather than one per restart
+type ConfigError struct {
+	Problems []string
+}
+
+// Error joins the problems into one line
+func (e *ConfigError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate reports settings that are out of range or can't work together,
+// returning a *ConfigError listing all of them
+func (c *Config) Validate() error {
+	problems := slices.Clone(c.envProblems)
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		addf("PORT must be a number from 1 to 65535, got %q", c.Port)
+	}
+	if c.EnableGRPC {
+		if port, err := strconv.Atoi(c.GRPCPort); err != nil || port < 1 || port > 65535 {
+			addf("GRPC_PORT must be a number from 1 to 65535, got %q", c.GRPCPort)
+		} else if c.GRPCPort == c.Port {
+			addf("GRPC_PORT must differ from PORT, both are %q", c.Port)
+		}
+		if c.DisableEcho {
+			addf("ENABLE_GRPC=true serves the echo service, so it requires ENABLE_ECHO")
+		}
+	}
+
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"READ_TIMEOUT", c.ReadTimeout},
+		{"READ_HEADER_TIMEOUT", c.ReadHeaderTimeout},
+		{"WRITE_TIMEOUT", c.WriteTimeout},
+		{"CONN_IDLE_TIMEOUT", c.IdleTimeout},
+		{"SHUTDOWN_TIMEOUT", c.ShutdownTimeout},
+		{"HANDLER_TIMEOUT", c.HandlerTimeout},
+		{"SLOW_REQUEST_THRESHOLD", c.SlowRequestThreshold},
+		{"HEALTH_TIMEOUT", c.HealthTimeout},
+		{"WARMUP_DURATION", c.WarmupDuration},
+		{"IDEMPOTENCY_TTL", c.IdempotencyTTL},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			addf("%s must not be negative, got %s", d.name, d.value)
+		}
+	}
+	var negativeRoutes []string
+	for path, timeout := range c.RouteTimeouts {
+		if timeout < 0 {
+			negativeRoutes = append(negativeRoutes, path)
+		}
+	}
+	slices.Sort(negativeRoutes)
+	for _, path := range negativeRoutes {
+		addf("route timeout for %s must not be negative, got %s", path, c.RouteTimeouts[path])
+	}
+
+	limits := []struct {
+		name  string
+		value int64
+	}{
+		{"MAX_MESSAGE_LENGTH", int64(c.MaxMessageLength)},
+		{"MAX_BODY_BYTES", c.MaxBodyBytes},
+		{"MAX_JSON_DEPTH", int64(c.MaxJSONDepth)},
+		{"MAX_JSON_TOKENS", int64(c.MaxJSONTokens)},
+		{"MAX_FILE_BYTES", c.MaxFileBytes},
+		{"MAX_URL_LENGTH", int64(c.MaxURLLength)},
+		{"MAX_HEADER_COUNT", int64(c.MaxHeaderCount)},
+		{"MAX_CONCURRENT", int64(c.MaxConcurrent)},
+		{"RATE_LIMIT_MAX_CLIENTS", int64(c.RateLimitMaxClients)},
+		{"ECHO_HISTORY_SIZE", int64(c.EchoHistorySize)},
+		{"CORS_MAX_AGE", int64(c.CORSMaxAge)},
+	}
+	for _, l := range limits {
+		if l.value < 0 {
+			addf("%s must not be negative, got %d", l.name, l.value)
+		}
+	}
+	if c.RateLimitRPS < 0 {
+		addf("RATE_LIMIT_RPS must not be negative, got %g", c.RateLimitRPS)
+	}
+	if c.RateLimitRPS > 0 && c.RateLimitBurst < 1 {
+		addf("RATE_LIMIT_BURST must be at least 1 when rate limiting is on, got %d", c.RateLimitBurst)
+	}
+	if c.IdempotencyTTL > 0 && c.IdempotencyMaxKeys < 1 {
+		addf("IDEMPOTENCY_MAX_KEYS must be at least 1 when IDEMPOTENCY_TTL is set, got %d", c.IdempotencyMaxKeys)
+	}
+
+	switch strings.ToLower(c.LogLevel) {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		addf("LOG_LEVEL must be debug, info, warn or error, got %q", c.LogLevel)
+	}
+	if !strings.EqualFold(c.LogFormat, LogFormatText) && !strings.EqualFold(c.LogFormat, LogFormatJSON) {
+		addf("LOG_FORMAT must be %s or %s, got %q", LogFormatText, LogFormatJSON, c.LogFormat)
+	}
+	if c.LogSampleRate < 0 || c.LogSampleRate > 1 {
+		addf("LOG_SAMPLE_RATE must be from 0 to 1, got %g", c.LogSampleRate)
+	}
+	if c.JSONCase != JSONCaseSnake && c.JSONCase != JSONCaseCamel {
+		addf("JSON_CASE must be %s or %s, got %q", JSONCaseSnake, JSONCaseCamel, c.JSONCase)
+	}
+	if c.MetricsPushURL != "" {
+		if u, err := url.Parse(c.MetricsPushURL); err != nil || (u.Scheme != "udp" && u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			addf("METRICS_PUSH_URL must be a udp://, http:// or https:// URL, got %q", c.MetricsPushURL)
+		}
+		if c.MetricsPushInterval <= 0 {
+			addf("METRICS_PUSH_INTERVAL must be positive when METRICS_PUSH_URL is set, got %s", c.MetricsPushInterval)
+		}
+	}
+	if c.PathPrefix != "" && !strings.HasPrefix(c.PathPrefix, "/") {
+		addf("PATH_PREFIX must start with /, got %q", c.PathPrefix)
+	}
+
+	if c.AllowCredentials && slices.Contains(c.CORSAllowedOrigins, "*") {
+		addf(`ALLOW_CREDENTIALS=true requires explicit CORS_ALLOWED_ORIGINS, not "*"`)
+	}
+	if _, err := parseTrustedProxies(c.TrustedProxies); err != nil {
+		addf("%v", err)
+	}
+
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+// middlewareDisabled reports whether the named middleware is listed in DisabledMiddleware
+func (c *Config) middlewareDisabled(name string) bool {
+	for _, disabled := range c.DisabledMiddleware {
+		if disabled == name {
+			return true
+		}
+	}
+	return false
+}
+
+// routeTimeoutEnv maps route paths to the environment variables overriding their timeouts
+var routeTimeoutEnv = map[string]string{
+	"/echo":        "ECHO_TIMEOUT",
+	"/echo/file":   "ECHO_FILE_TIMEOUT",
+	"/echo/ndjson": "ECHO_NDJSON_TIMEOUT",
+	"/healthz":     "HEALTHZ_TIMEOUT",
+}
+
+// DefaultConfig returns the configuration used when nothing is overridden
+func DefaultConfig() Config {
+	return Config{
+		Port:                 "8080",
+		GRPCPort:             "9090",
+		ReadTimeout:          10 * time.Second,
+		ReadHeaderTimeout:    5 * time.Second,
+		WriteTimeout:         10 * time.Second,
+		IdleTimeout:          60 * time.Second,
+		ShutdownTimeout:      10 * time.Second,
+		HandlerTimeout:       5 * time.Second,
+		RouteTimeouts:        map[string]time.Duration{"/healthz": 2 * time.Second},
+		CacheControl:         defaultCacheControl(),
+		HealthTimeout:        2 * time.Second,
+		MetricsPushInterval:  15 * time.Second,
+		SlowRequestThreshold: time.Second,
+		LogLevel:             "info",
+		LogFormat:            LogFormatText,
+		LogSampleRate:        1,
+		Greeting:             "Welcome to PingMe API!",
+		EchoPrefix:           "Echo: ",
+		JSONCase:             JSONCaseSnake,
+		MaxURLLength:         8192,
+		MaxHeaderCount:       100,
+		MaxMessageLength:     10000,
+		MaxBodyBytes:         1 << 20,
+		MaxJSONDepth:         32,
+		MaxJSONTokens:        1000,
+		MaxFileBytes:         512 << 10,
+
+		Gzip: true,
+
+		RateLimitBurst:      10,
+		RateLimitMaxClients: 10000,
+
+		IdempotencyTTL:     10 * time.Minute,
+		IdempotencyMaxKeys: 1000,
+
+		CORSMaxAge: 600,
+	}
+}
+
+// LoadConfig returns DefaultConfig overridden by settings from the environment
+func LoadConfig() Config {
+	cfg := DefaultConfig()
+	var env envLoader
+	cfg.Bind = getenv("BIND", cfg.Bind)
+	cfg.ReusePort = env.getBool("REUSE_PORT", cfg.ReusePort)
+	cfg.EnableGRPC = env.getBool("ENABLE_GRPC", cfg.EnableGRPC)
+	cfg.GRPCPort = getenv("GRPC_PORT", cfg.GRPCPort)
+	cfg.ReadTimeout = env.getDuration("READ_TIMEOUT", cfg.ReadTimeout)
+	cfg.ReadHeaderTimeout = env.getDuration("READ_HEADER_TIMEOUT", cfg.ReadHeaderTimeout)
+	cfg.WriteTimeout = env.getDuration("WRITE_TIMEOUT", cfg.WriteTimeout)
+	cfg.ShutdownTimeout = env.getDuration("SHUTDOWN_TIMEOUT", cfg.ShutdownTimeout)
+	cfg.IdleTimeout = env.getDuration("CONN_IDLE_TIMEOUT", cfg.IdleTimeout)
+	cfg.DisableKeepAlive = env.getBool("DISABLE_KEEPALIVE", cfg.DisableKeepAlive)
+	cfg.HandlerTimeout = env.getDuration("HANDLER_TIMEOUT", cfg.HandlerTimeout)
+	for path, key := range routeTimeoutEnv {
+		if timeout, ok := cfg.RouteTimeouts[path]; ok {
+			cfg.RouteTimeouts[path] = env.getDuration(key, timeout)
+		} else if timeout := env.getDuration(key, -1); timeout >= 0 {
+			cfg.RouteTimeouts[path] = timeout
+		}
+	}
+	for path, key := range cacheControlEnv {
+		if policy, ok := os.LookupEnv(key); ok {
+			cfg.CacheControl[path] = policy
+		}
+	}
+	cfg.HealthTimeout = env.getDuration("HEALTH_TIMEOUT", cfg.HealthTimeout)
+	cfg.WarmupDuration = env.getDuration("WARMUP_DURATION", cfg.WarmupDuration)
+	cfg.MetricsPushURL = getenv("METRICS_PUSH_URL", cfg.MetricsPushURL)
+	cfg.MetricsPushInterval = env.getDuration("METRICS_PUSH_INTERVAL", cfg.MetricsPushInterval)
+	cfg.SlowRequestThreshold = env.getDuration("SLOW_REQUEST_THRESHOLD", cfg.SlowRequestThreshold)
+	cfg.LogLevel = getenv("LOG_LEVEL", cfg.LogLevel)
+	cfg.LogFormat = getenv("LOG_FORMAT", cfg.LogFormat)
+	cfg.LogFile = getenv("LOG_FILE", cfg.LogFile)
+	cfg.LogSampleRate = env.getFloat("LOG_SAMPLE_RATE", cfg.LogSampleRate)
+	cfg.Greeting = getenv("GREETING", cfg.Greeting)
+	// LookupEnv rather than getenv so ECHO_PREFIX= selects an empty prefix
+	if prefix, ok := os.LookupEnv("ECHO_PREFIX"); ok {
+		cfg.EchoPrefix = prefix
+	}
+	cfg.DisableGreeting = env.getBool("DISABLE_GREETING", cfg.DisableGreeting)
+	// ENABLE_* flags default to true and switch a route group off when false
+	cfg.DisableGreeting = !env.getBool("ENABLE_GREETING", !cfg.DisableGreeting)
+	cfg.DisableEcho = !env.getBool("ENABLE_ECHO", !cfg.DisableEcho)
+	cfg.AdminAPIKey = getenv("ADMIN_API_KEY", cfg.AdminAPIKey)
+	cfg.JSONCase = getenv("JSON_CASE", cfg.JSONCase)
+	cfg.StrictEnvelope = env.getBool("STRICT_ENVELOPE", cfg.StrictEnvelope)
+	cfg.CompactJSON = env.getBool("COMPACT_JSON", cfg.CompactJSON)
+	cfg.PoolBuffers = env.getBool("POOL_BUFFERS", cfg.PoolBuffers)
+	cfg.StrictUTF8 = env.getBool("STRICT_UTF8", cfg.StrictUTF8)
+	cfg.ResponseWrapper = getenv("RESPONSE_WRAPPER", cfg.ResponseWrapper)
+	cfg.MaxMessageLength = env.getInt("MAX_MESSAGE_LENGTH", cfg.MaxMessageLength)
+	cfg.MaxBodyBytes = env.getInt64("MAX_BODY_BYTES", cfg.MaxBodyBytes)
+	cfg.MaxJSONDepth = env.getInt("MAX_JSON_DEPTH", cfg.MaxJSONDepth)
+	cfg.MaxJSONTokens = env.getInt("MAX_JSON_TOKENS", cfg.MaxJSONTokens)
+	cfg.MaxFileBytes = env.getInt64("MAX_FILE_BYTES", cfg.MaxFileBytes)
+	cfg.MaxURLLength = env.getInt("MAX_URL_LENGTH", cfg.MaxURLLength)
+	cfg.MaxHeaderCount = env.getInt("MAX_HEADER_COUNT", cfg.MaxHeaderCount)
+	cfg.PathPrefix = getenv("PATH_PREFIX", cfg.PathPrefix)
+	cfg.DebugLogBodies = env.getBool("DEBUG_LOG_BODIES", cfg.DebugLogBodies)
+	cfg.TrustProxy = env.getBool("TRUST_PROXY", cfg.TrustProxy)
+	cfg.TrustedProxies = getenvList("TRUSTED_PROXIES", cfg.TrustedProxies)
+	cfg.RateLimitRPS = env.getFloat("RATE_LIMIT_RPS", cfg.RateLimitRPS)
+	cfg.RateLimitBurst = env.getInt("RATE_LIMIT_BURST", cfg.RateLimitBurst)
+	cfg.RateLimitMaxClients = env.getInt("RATE_LIMIT_MAX_CLIENTS", cfg.RateLimitMaxClients)
+	cfg.LogConnState = env.getBool("LOG_CONN_STATE", cfg.LogConnState)
+	cfg.Gzip = env.getBool("GZIP", cfg.Gzip)
+	cfg.MaxConcurrent = env.getInt("MAX_CONCURRENT", cfg.MaxConcurrent)
+	cfg.IdempotencyTTL = env.getDuration("IDEMPOTENCY_TTL", cfg.IdempotencyTTL)
+	cfg.EchoHistorySize = env.getInt("ECHO_HISTORY_SIZE", cfg.EchoHistorySize)
+	cfg.IdempotencyMaxKeys = env.getInt("IDEMPOTENCY_MAX_KEYS", cfg.IdempotencyMaxKeys)
+	cfg.CORSAllowedOrigins = getenvList("CORS_ALLOWED_ORIGINS", cfg.CORSAllowedOrigins)
+	cfg.CORSMaxAge = env.getInt("CORS_MAX_AGE", cfg.CORSMaxAge)
+	cfg.AllowCredentials = env.getBool("ALLOW_CREDENTIALS", cfg.AllowCredentials)
+	cfg.ResponseSigningKey = getenv("RESPONSE_SIGNING_KEY", cfg.ResponseSigningKey)
+	cfg.MaintenanceMode = env.getBool("MAINTENANCE_MODE", cfg.MaintenanceMode)
+	cfg.DisabledMiddleware = getenvList("DISABLE_MIDDLEWARE", cfg.DisabledMiddleware)
+	cfg.envProblems = env.problems
+	return cfg
+}
+
+// getenv returns the environment variable named by key, or fallback when unset
+func getenv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// envLoader reads typed settings from the environment. A value that is set
+// but doesn't parse leaves the fallback in place and is recorded as a
+// problem, so Validate refuses it rather than running on the default.
+type envLoader struct {
+	problems []string
+}
+
+// lookup returns the environment variable named by key and whether it is set
+// to something other than the empty string
+func (e *envLoader) lookup(key string) (string, bool) {
+	value := os.Getenv(key)
+	return value, value != ""
+}
+
+// invalid records that key holds value, which isn't the kind of value wanted
+func (e *envLoader) invalid(key, value, want string) {
+	e.problems = append(e.problems, fmt.Sprintf("%s must be %s, got %q", key, want, value))
+}
+
+// getInt returns the integer environment variable named by key, or fallback
+// when it is unset or not a valid integer
+func (e *envLoader) getInt(key string, fallback int) int {
+	raw, ok := e.lookup(key)
+	if !ok {
+		return fallback
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		e.invalid(key, raw, "an integer")
+		return fallback
+	}
+	return value
+}
+
+// getInt64 returns the 64-bit integer environment variable named by key, or
+// fallback when it is unset or not a valid integer
+func (e *envLoader) getInt64(key string, fallback int64) int64 {
+	raw, ok := e.lookup(key)
+	if !ok {
+		return fallback
+	}
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		e.invalid(key, raw, "an integer")
+		return fallback
+	}
+	return value
+}
+
+// getFloat returns the numeric environment variable named by key, or fallback
+// when it is unset or not a valid number
+func (e *envLoader) getFloat(key string, fallback float64) float64 {
+	raw, ok := e.lookup(key)
+	if !ok {
+		return fallback
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		e.invalid(key, raw, "a number")
+		return fallback
+	}
+	return value
+}
+
+// getDuration returns the duration environment variable named by key, such as
+// "5s", or fallback when it is unset or not a valid duration
+func (e *envLoader) getDuration(key string, fallback time.Duration) time.Duration {
+	raw, ok := e.lookup(key)
+	if !ok {
+		return fallback
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		e.invalid(key, raw, `a duration with a unit, such as "5s"`)
+		return fallback
+	}
+	return value
+}
+
+// getBool returns the boolean environment variable named by key, or fallback
+// when it is unset or not a valid boolean
+func (e *envLoader) getBool(key string, fallback bool) bool {
+	raw, ok := e.lookup(key)
+	if !ok {
+		return fallback
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		e.invalid(key, raw, "true or false")
+		return fallback
+	}
+	return value
+}
+
+// getenvList returns the comma-separated environment variable named by key as
+// a list of trimmed, non-empty values, or fallback when it is unset
+func getenvList(key string, fallback []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,105 @@
+package pingme
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestConfigValidateDefaults tests that the default configuration is valid
+func TestConfigValidateDefaults(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected the defaults to be valid, got %v", err)
+	}
+}
+
+// TestConfigValidateProblems tests that each invalid setting is reported with its own message
+func TestConfigValidateProblems(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(*Config)
+		expected string
+	}{
+		{"port out of range", func(c *Config) { c.Port = "70000" }, `PORT must be a number from 1 to 65535, got "70000"`},
+		{"port not a number", func(c *Config) { c.Port = "http" }, `PORT must be a number from 1 to 65535, got "http"`},
+		{"grpc port out of range", func(c *Config) { c.EnableGRPC = true; c.GRPCPort = "0" }, `GRPC_PORT must be a number from 1 to 65535, got "0"`},
+		{"grpc port shared", func(c *Config) { c.EnableGRPC = true; c.GRPCPort = c.Port }, `GRPC_PORT must differ from PORT, both are "8080"`},
+		{"grpc without echo", func(c *Config) { c.EnableGRPC = true; c.DisableEcho = true }, "ENABLE_GRPC=true serves the echo service, so it requires ENABLE_ECHO"},
+		{"negative timeout", func(c *Config) { c.WriteTimeout = -time.Second }, "WRITE_TIMEOUT must not be negative, got -1s"},
+		{"negative route timeout", func(c *Config) { c.RouteTimeouts = map[string]time.Duration{"/echo": -time.Second} }, "route timeout for /echo must not be negative, got -1s"},
+		{"negative limit", func(c *Config) { c.MaxBodyBytes = -1 }, "MAX_BODY_BYTES must not be negative, got -1"},
+		{"rate limit without burst", func(c *Config) { c.RateLimitRPS = 5; c.RateLimitBurst = 0 }, "RATE_LIMIT_BURST must be at least 1 when rate limiting is on, got 0"},
+		{"idempotency without keys", func(c *Config) { c.IdempotencyMaxKeys = 0 }, "IDEMPOTENCY_MAX_KEYS must be at least 1 when IDEMPOTENCY_TTL is set, got 0"},
+		{"unknown log level", func(c *Config) { c.LogLevel = "verbose" }, `LOG_LEVEL must be debug, info, warn or error, got "verbose"`},
+		{"unknown log format", func(c *Config) { c.LogFormat = "xml" }, `LOG_FORMAT must be text or json, got "xml"`},
+		{"sample rate above one", func(c *Config) { c.LogSampleRate = 1.5 }, "LOG_SAMPLE_RATE must be from 0 to 1, got 1.5"},
+		{"unsupported push URL", func(c *Config) { c.MetricsPushURL = "tcp://localhost:8125" }, `METRICS_PUSH_URL must be a udp://, http:// or https:// URL, got "tcp://localhost:8125"`},
+		{"push without interval", func(c *Config) { c.MetricsPushURL = "udp://localhost:8125"; c.MetricsPushInterval = 0 }, "METRICS_PUSH_INTERVAL must be positive when METRICS_PUSH_URL is set, got 0s"},
+		{"unknown JSON case", func(c *Config) { c.JSONCase = "kebab" }, `JSON_CASE must be snake or camel, got "kebab"`},
+		{"relative path prefix", func(c *Config) { c.PathPrefix = "pingme" }, `PATH_PREFIX must start with /, got "pingme"`},
+		{"credentials with wildcard", func(c *Config) { c.CORSAllowedOrigins = []string{"*"}; c.AllowCredentials = true }, `ALLOW_CREDENTIALS=true requires explicit CORS_ALLOWED_ORIGINS, not "*"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(&cfg)
+
+			var cfgErr *ConfigError
+			if err := cfg.Validate(); !errors.As(err, &cfgErr) {
+				t.Fatalf("expected a *ConfigError, got %v", err)
+			}
+			if !slices.Equal(cfgErr.Problems, []string{tt.expected}) {
+				t.Errorf("expected problems [%s], got %q", tt.expected, cfgErr.Problems)
+			}
+		})
+	}
+}
+
+// TestConfigValidateAggregates tests that every problem is reported at once
+func TestConfigValidateAggregates(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ReadTimeout = -time.Second
+	cfg.LogLevel = "loud"
+	cfg.TrustedProxies = []string{"not-a-cidr"}
+
+	var cfgErr *ConfigError
+	if err := cfg.Validate(); !errors.As(err, &cfgErr) {
+		t.Fatalf("expected a *ConfigError, got %v", err)
+	}
+	expected := []string{
+		"READ_TIMEOUT must not be negative, got -1s",
+		`LOG_LEVEL must be debug, info, warn or error, got "loud"`,
+		`TRUSTED_PROXIES: "not-a-cidr" is not a CIDR or IP address`,
+	}
+	if !slices.Equal(cfgErr.Problems, expected) {
+		t.Errorf("expected problems %q, got %q", expected, cfgErr.Problems)
+	}
+	if got := cfgErr.Error(); got != "invalid configuration: "+expected[0]+"; "+expected[1]+"; "+expected[2] {
+		t.Errorf("unexpected error text %q", got)
+	}
+}
+
+// TestLoadConfigMalformedValues tests that environment values which don't
+// parse are reported by Validate instead of falling back to the defaults
+func TestLoadConfigMalformedValues(t *testing.T) {
+	t.Setenv("MAX_BODY_BYTES", "abc")
+	t.Setenv("READ_TIMEOUT", "5")
+	t.Setenv("GZIP", "sometimes")
+
+	cfg := LoadConfig()
+	var cfgErr *ConfigError
+	if err := cfg.Validate(); !errors.As(err, &cfgErr) {
+		t.Fatalf("expected a *ConfigError, got %v", err)
+	}
+	expected := []string{
+		`READ_TIMEOUT must be a duration with a unit, such as "5s", got "5"`,
+		`MAX_BODY_BYTES must be an integer, got "abc"`,
+		`GZIP must be true or false, got "sometimes"`,
+	}
+	if !slices.Equal(cfgErr.Problems, expected) {
+		t.Errorf("expected problems %q, got %q", expected, cfgErr.Problems)
+	}
+}
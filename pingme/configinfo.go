@@ -0,0 +1,154 @@
+package pingme
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// ConfigData is the effective non-secret configuration returned by GET /config.
+// Secrets such as AdminAPIKey are deliberately left out.
+type ConfigData struct {
+	Bind                 string            `json:"bind"`
+	Port                 string            `json:"port"`
+	ReusePort            bool              `json:"reuse_port"`
+	EnableGRPC           bool              `json:"enable_grpc"`
+	GRPCPort             string            `json:"grpc_port"`
+	ReadTimeout          string            `json:"read_timeout"`
+	ReadHeaderTimeout    string            `json:"read_header_timeout"`
+	WriteTimeout         string            `json:"write_timeout"`
+	IdleTimeout          string            `json:"idle_timeout"`
+	DisableKeepAlive     bool              `json:"disable_keepalive"`
+	ShutdownTimeout      string            `json:"shutdown_timeout"`
+	HandlerTimeout       string            `json:"handler_timeout"`
+	RouteTimeouts        map[string]string `json:"route_timeouts"`
+	CacheControl         map[string]string `json:"cache_control"`
+	HealthTimeout        string            `json:"health_timeout"`
+	WarmupDuration       string            `json:"warmup_duration"`
+	MetricsPushURL       string            `json:"metrics_push_url"`
+	MetricsPushInterval  string            `json:"metrics_push_interval"`
+	SlowRequestThreshold string            `json:"slow_request_threshold"`
+	Greeting             string            `json:"greeting"`
+	EchoPrefix           string            `json:"echo_prefix"`
+	LogLevel             string            `json:"log_level"`
+	LogFormat            string            `json:"log_format"`
+	LogFile              string            `json:"log_file"`
+	LogSampleRate        float64           `json:"log_sample_rate"`
+	JSONCase             string            `json:"json_case"`
+	PathPrefix           string            `json:"path_prefix"`
+	MaxMessageLength     int               `json:"max_message_length"`
+	MaxBodyBytes         int64             `json:"max_body_bytes"`
+	MaxJSONDepth         int               `json:"max_json_depth"`
+	MaxJSONTokens        int               `json:"max_json_tokens"`
+	MaxFileBytes         int64             `json:"max_file_bytes"`
+	MaxURLLength         int               `json:"max_url_length"`
+	MaxHeaderCount       int               `json:"max_header_count"`
+	MaxConcurrent        int               `json:"max_concurrent"`
+	RateLimitRPS         float64           `json:"rate_limit_rps"`
+	RateLimitBurst       int               `json:"rate_limit_burst"`
+	RateLimitMaxClients  int               `json:"rate_limit_max_clients"`
+	IdempotencyTTL       string            `json:"idempotency_ttl"`
+	IdempotencyMaxKeys   int               `json:"idempotency_max_keys"`
+	EchoHistorySize      int               `json:"echo_history_size"`
+	CORSAllowedOrigins   []string          `json:"cors_allowed_origins"`
+	CORSMaxAge           int               `json:"cors_max_age"`
+	AllowCredentials     bool              `json:"allow_credentials"`
+	TrustProxy           bool              `json:"trust_proxy"`
+	TrustedProxies       []string          `json:"trusted_proxies"`
+	StrictEnvelope       bool              `json:"strict_envelope"`
+	StrictUTF8           bool              `json:"strict_utf8"`
+	CompactJSON          bool              `json:"compact_json"`
+	PoolBuffers          bool              `json:"pool_buffers"`
+	ResponseWrapper      string            `json:"response_wrapper"`
+	MaintenanceMode      bool              `json:"maintenance_mode"`
+	ResponseSigning      bool              `json:"response_signing"`
+	Middleware           []string          `json:"middleware"`
+}
+
+// configData builds the ConfigData view of cfg
+func (s *Server) configData(cfg *Config) ConfigData {
+	middleware := []string{}
+	for _, mw := range enabledMiddlewares(cfg, s.middlewareRegistry()) {
+		middleware = append(middleware, mw.name)
+	}
+	routeTimeouts := make(map[string]string, len(cfg.RouteTimeouts))
+	for path, timeout := range cfg.RouteTimeouts {
+		routeTimeouts[path] = timeout.String()
+	}
+	return ConfigData{
+		Bind:                 cfg.Bind,
+		Port:                 cfg.Port,
+		ReusePort:            cfg.ReusePort,
+		EnableGRPC:           cfg.EnableGRPC,
+		GRPCPort:             cfg.GRPCPort,
+		ReadTimeout:          cfg.ReadTimeout.String(),
+		ReadHeaderTimeout:    cfg.ReadHeaderTimeout.String(),
+		WriteTimeout:         cfg.WriteTimeout.String(),
+		IdleTimeout:          cfg.IdleTimeout.String(),
+		DisableKeepAlive:     cfg.DisableKeepAlive,
+		ShutdownTimeout:      cfg.ShutdownTimeout.String(),
+		HandlerTimeout:       cfg.HandlerTimeout.String(),
+		RouteTimeouts:        routeTimeouts,
+		CacheControl:         cfg.CacheControl,
+		HealthTimeout:        cfg.HealthTimeout.String(),
+		WarmupDuration:       cfg.WarmupDuration.String(),
+		MetricsPushURL:       redactURL(cfg.MetricsPushURL),
+		MetricsPushInterval:  cfg.MetricsPushInterval.String(),
+		SlowRequestThreshold: cfg.SlowRequestThreshold.String(),
+		Greeting:             cfg.Greeting,
+		EchoPrefix:           cfg.EchoPrefix,
+		LogLevel:             cfg.LogLevel,
+		LogFormat:            cfg.LogFormat,
+		LogFile:              cfg.LogFile,
+		LogSampleRate:        cfg.LogSampleRate,
+		JSONCase:             cfg.JSONCase,
+		PathPrefix:           cfg.PathPrefix,
+		MaxMessageLength:     cfg.MaxMessageLength,
+		MaxBodyBytes:         cfg.MaxBodyBytes,
+		MaxJSONDepth:         cfg.MaxJSONDepth,
+		MaxJSONTokens:        cfg.MaxJSONTokens,
+		MaxFileBytes:         cfg.MaxFileBytes,
+		MaxURLLength:         cfg.MaxURLLength,
+		MaxHeaderCount:       cfg.MaxHeaderCount,
+		MaxConcurrent:        cfg.MaxConcurrent,
+		RateLimitRPS:         cfg.RateLimitRPS,
+		RateLimitBurst:       cfg.RateLimitBurst,
+		RateLimitMaxClients:  cfg.RateLimitMaxClients,
+		IdempotencyTTL:       cfg.IdempotencyTTL.String(),
+		IdempotencyMaxKeys:   cfg.IdempotencyMaxKeys,
+		EchoHistorySize:      cfg.EchoHistorySize,
+		CORSAllowedOrigins:   append([]string{}, cfg.CORSAllowedOrigins...),
+		CORSMaxAge:           cfg.CORSMaxAge,
+		AllowCredentials:     cfg.AllowCredentials,
+		TrustProxy:           cfg.TrustProxy,
+		TrustedProxies:       append([]string{}, cfg.TrustedProxies...),
+		StrictEnvelope:       cfg.StrictEnvelope,
+		StrictUTF8:           cfg.StrictUTF8,
+		CompactJSON:          cfg.CompactJSON,
+		PoolBuffers:          cfg.PoolBuffers,
+		ResponseWrapper:      cfg.ResponseWrapper,
+		MaintenanceMode:      cfg.MaintenanceMode,
+		ResponseSigning:      cfg.ResponseSigningKey != "",
+		Middleware:           middleware,
+	}
+}
+
+// redactURL hides any password in raw, which Validate has already parsed
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return u.Redacted()
+}
+
+// configHandler handles GET /config requests
+func (s *Server) configHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdminKey(w, r) {
+		return
+	}
+	s.respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Configuration retrieved successfully",
+		Data:    s.configData(s.config()),
+	})
+}
@@ -0,0 +1,60 @@
+package pingme
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestConfigEndpoint tests that /config reports timeouts and never the admin key
+func TestConfigEndpoint(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AdminAPIKey = "super-secret"
+	cfg.ReadTimeout = 3 * time.Second
+	cfg.HandlerTimeout = 750 * time.Millisecond
+	server := NewServer(cfg)
+
+	req := httptest.NewRequest(http.MethodGet, "/config", nil)
+	req.Header.Set("X-API-Key", "super-secret")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "super-secret") {
+		t.Error("expected the admin API key to be absent from /config")
+	}
+
+	var response struct {
+		Data ConfigData `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Data.ReadTimeout != "3s" {
+		t.Errorf("expected read_timeout 3s, got %q", response.Data.ReadTimeout)
+	}
+	if response.Data.HandlerTimeout != "750ms" {
+		t.Errorf("expected handler_timeout 750ms, got %q", response.Data.HandlerTimeout)
+	}
+	if len(response.Data.Middleware) == 0 || response.Data.Middleware[0] != "request_id" {
+		t.Errorf("expected enabled middleware starting with request_id, got %v", response.Data.Middleware)
+	}
+}
+
+// TestConfigEndpointRequiresKey tests that /config is guarded by the admin key
+func TestConfigEndpointRequiresKey(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AdminAPIKey = "super-secret"
+	w := httptest.NewRecorder()
+
+	NewServer(cfg).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/config", nil))
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", w.Code)
+	}
+}
@@ -0,0 +1,68 @@
+package pingme
+
+import (
+	"net"
+	"net/http"
+	"sync/atomic"
+)
+
+// ConnStats counts connection state transitions seen by Server.ConnState
+type ConnStats struct {
+	New    int64 `json:"new"`
+	Active int64 `json:"active"`
+	Idle   int64 `json:"idle"`
+	Closed int64 `json:"closed"`
+}
+
+// StatsData represents the data returned by GET /stats
+type StatsData struct {
+	Connections ConnStats `json:"connections"`
+}
+
+// connCounters holds the live counters behind ConnStats
+type connCounters struct {
+	new, active, idle, closed atomic.Int64
+}
+
+// snapshot copies the counters into a ConnStats
+func (c *connCounters) snapshot() ConnStats {
+	return ConnStats{
+		New:    c.new.Load(),
+		Active: c.active.Load(),
+		Idle:   c.idle.Load(),
+		Closed: c.closed.Load(),
+	}
+}
+
+// ConnState counts connection state changes, and logs them when
+// Config.LogConnState is set. Assign it to http.Server.ConnState to fill
+// in /stats and OpenConnections.
+func (s *Server) ConnState(conn net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		s.conns.new.Add(1)
+	case http.StateActive:
+		s.conns.active.Add(1)
+	case http.StateIdle:
+		s.conns.idle.Add(1)
+	case http.StateClosed, http.StateHijacked:
+		s.conns.closed.Add(1)
+	}
+	if s.config().LogConnState {
+		s.logger.Debug("Connection state changed", "remote_addr", conn.RemoteAddr().String(), "state", state.String())
+	}
+}
+
+// OpenConnections returns how many connections seen by ConnState are not yet closed
+func (s *Server) OpenConnections() int64 {
+	return s.conns.new.Load() - s.conns.closed.Load()
+}
+
+// statsHandler handles GET /stats requests
+func (s *Server) statsHandler(w http.ResponseWriter, r *http.Request) {
+	s.respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Statistics retrieved successfully",
+		Data:    StatsData{Connections: s.conns.snapshot()},
+	})
+}
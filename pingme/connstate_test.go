@@ -0,0 +1,61 @@
+package pingme
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// getStats fetches GET /stats through the handler
+func getStats(t *testing.T, server *Server) ConnStats {
+	t.Helper()
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stats", nil))
+
+	var response struct {
+		Data StatsData `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode stats: %v", err)
+	}
+	return response.Data.Connections
+}
+
+// TestConnStateCounters tests that real connections move the new and closed counters
+func TestConnStateCounters(t *testing.T) {
+	server := newTestServer()
+	ts := httptest.NewUnstartedServer(server)
+	ts.Config.ConnState = server.ConnState
+	ts.Start()
+	defer ts.Close()
+
+	client := ts.Client()
+	for i := 0; i < 2; i++ {
+		res, err := client.Get(ts.URL + "/healthz")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		res.Body.Close()
+	}
+	client.CloseIdleConnections()
+
+	// The server notices the closed connection asynchronously
+	deadline := time.Now().Add(2 * time.Second)
+	stats := getStats(t, server)
+	for stats.Closed < 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		stats = getStats(t, server)
+	}
+
+	if stats.New < 1 {
+		t.Errorf("expected at least 1 new connection, got %d", stats.New)
+	}
+	if stats.Active < 2 {
+		t.Errorf("expected at least 2 active transitions, got %d", stats.Active)
+	}
+	if stats.Closed < 1 {
+		t.Errorf("expected at least 1 closed connection, got %d", stats.Closed)
+	}
+}
@@ -0,0 +1,56 @@
+package pingme
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// corsOrigin returns the Access-Control-Allow-Origin value for a request
+// origin, or "" when it isn't allowed. Credentials mode always names the
+// origin, since browsers reject "*" alongside credentials.
+func corsOrigin(cfg *Config, origin string) string {
+	if slices.Contains(cfg.CORSAllowedOrigins, origin) {
+		return origin
+	}
+	if slices.Contains(cfg.CORSAllowedOrigins, "*") && !cfg.AllowCredentials {
+		return "*"
+	}
+	return ""
+}
+
+// cors adds CORS headers for allowed origins. Preflights fall through to
+// the route's OPTIONS handler, whose Allow header becomes
+// Access-Control-Allow-Methods.
+func (s *Server) cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := s.config()
+		origin := r.Header.Get("Origin")
+		allowed := corsOrigin(cfg, origin)
+		w.Header().Add("Vary", "Origin")
+		if origin == "" || allowed == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("Access-Control-Allow-Origin", allowed)
+		if cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+			h.Set("Access-Control-Allow-Headers", headers)
+		}
+		h.Set("Access-Control-Max-Age", strconv.Itoa(cfg.CORSMaxAge))
+		next.ServeHTTP(newResponseRecorder(w, func(h http.Header) {
+			if allow := h.Get("Allow"); allow != "" {
+				h.Set("Access-Control-Allow-Methods", allow)
+			}
+		}), r)
+	})
+}
@@ -0,0 +1,103 @@
+package pingme
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// corsServer builds a server allowing the given origins
+func corsServer(credentials bool, origins ...string) *Server {
+	cfg := DefaultConfig()
+	cfg.CORSAllowedOrigins = origins
+	cfg.AllowCredentials = credentials
+	return NewServer(cfg)
+}
+
+// preflight sends a CORS preflight for POST /echo from origin
+func preflight(server *Server, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, "/echo", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+// TestCORSPreflight tests the preflight headers, including the max-age
+func TestCORSPreflight(t *testing.T) {
+	w := preflight(corsServer(false, "*"), "https://app.example.com")
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected status 204, got %d", w.Code)
+	}
+	expected := map[string]string{
+		"Access-Control-Allow-Origin":  "*",
+		"Access-Control-Allow-Methods": "GET, POST, HEAD, OPTIONS",
+		"Access-Control-Allow-Headers": "Content-Type",
+		"Access-Control-Max-Age":       "600",
+	}
+	for header, value := range expected {
+		if got := w.Header().Get(header); got != value {
+			t.Errorf("expected %s %q, got %q", header, value, got)
+		}
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("expected no credentials header, got %q", got)
+	}
+}
+
+// TestCORSCredentials tests that credentials mode echoes the specific origin
+func TestCORSCredentials(t *testing.T) {
+	w := preflight(corsServer(true, "https://app.example.com"), "https://app.example.com")
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("expected the request origin to be echoed, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("expected Access-Control-Allow-Credentials true, got %q", got)
+	}
+	if got := w.Header().Get("Vary"); got != "Origin" {
+		t.Errorf("expected Vary Origin, got %q", got)
+	}
+}
+
+// TestCORSDisallowedOrigin tests that unlisted origins get no CORS headers
+func TestCORSDisallowedOrigin(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	w := httptest.NewRecorder()
+
+	corsServer(false, "https://app.example.com").ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("expected no Access-Control-Allow-Origin, got %q", got)
+	}
+}
+
+// TestConfigValidateCredentialsWildcard tests that credentials with a "*" origin is rejected
+func TestConfigValidateCredentialsWildcard(t *testing.T) {
+	tests := []struct {
+		name        string
+		origins     []string
+		credentials bool
+		valid       bool
+	}{
+		{"wildcard", []string{"*"}, false, true},
+		{"credentials with explicit origin", []string{"https://app.example.com"}, true, true},
+		{"credentials with wildcard", []string{"https://app.example.com", "*"}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.CORSAllowedOrigins = tt.origins
+			cfg.AllowCredentials = tt.credentials
+
+			if err := cfg.Validate(); (err == nil) != tt.valid {
+				t.Errorf("expected valid %v, got error %v", tt.valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package pingme
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error codes sent as Response.ErrorCode. Unlike Error messages they are
+// stable, so clients can branch on them.
+const (
+	codeInvalidJSON           = "invalid_json"
+	codeInvalidBody           = "invalid_body"
+	codeInvalidMessageType    = "invalid_message_type"
+	codeJSONTooComplex        = "json_too_complex"
+	codeEmptyMessage          = "empty_message"
+	codeMessageTooLong        = "message_too_long"
+	codeInvalidMode           = "invalid_mode"
+	codeInvalidMessage        = "invalid_message"
+	codeInvalidUTF8           = "invalid_utf8"
+	codeInvalidDelay          = "invalid_delay"
+	codeInvalidFields         = "invalid_fields"
+	codeMissingFile           = "missing_file"
+	codePayloadTooLarge       = "payload_too_large"
+	codeURITooLong            = "uri_too_long"
+	codeHeadersTooLarge       = "headers_too_large"
+	codeUnsupportedMediaType  = "unsupported_media_type"
+	codeNotFound              = "not_found"
+	codeMethodNotAllowed      = "method_not_allowed"
+	codeUnauthorized          = "unauthorized"
+	codeAdminDisabled         = "admin_disabled"
+	codeRateLimited           = "rate_limited"
+	codeOverloaded            = "overloaded"
+	codeTimeout               = "timeout"
+	codeGatewayTimeout        = "gateway_timeout"
+	codeUnhealthy             = "unhealthy"
+	codeNotReady              = "not_ready"
+	codeMaintenance           = "maintenance"
+	codeInvalidConfig         = "invalid_config"
+	codeIdempotencyKeyReused  = "idempotency_key_reused"
+	codeIdempotencyInProgress = "idempotency_in_progress"
+	codeInternal              = "internal_error"
+)
+
+// apiError is an error response: the HTTP status, a machine-readable code,
+// the message shown to clients and any data to send alongside it
+type apiError struct {
+	Status  int
+	Code    string
+	Message string
+	Data    interface{}
+}
+
+// Error returns the client-facing message
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+// newAPIError creates an apiError with a formatted message
+func newAPIError(status int, code, format string, args ...interface{}) *apiError {
+	return &apiError{Status: status, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// errorCode returns the code of an apiError, or internal_error for anything else
+func errorCode(err error) string {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return codeInternal
+}
+
+// respondError sends err as a JSON error response. Errors other than
+// apiError are logged and reported as a 500 without their details.
+func (s *Server) respondError(w http.ResponseWriter, err error) {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		s.logger.Error("Unexpected handler error", "error", err)
+		apiErr = newAPIError(http.StatusInternalServerError, codeInternal, "Internal server error")
+	}
+	s.respondJSON(w, apiErr.Status, Response{
+		Success:   false,
+		Data:      apiErr.Data,
+		Error:     apiErr.Message,
+		ErrorCode: apiErr.Code,
+	})
+}
@@ -0,0 +1,88 @@
+package pingme
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// assertError checks that a response is an error envelope with the given status and code
+func assertError(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+
+	if w.Code != status {
+		t.Errorf("expected status %d, got %d", status, w.Code)
+	}
+	var response Response
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Success {
+		t.Error("expected success to be false")
+	}
+	if response.Error == "" {
+		t.Error("expected an error message")
+	}
+	if response.ErrorCode != code {
+		t.Errorf("expected error_code %q, got %q", code, response.ErrorCode)
+	}
+}
+
+// TestErrorCodes tests that error responses across endpoints share one shape with a stable code
+func TestErrorCodes(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		status      int
+		code        string
+	}{
+		{"empty message", http.MethodPost, "/echo", "application/json", `{"message": ""}`, http.StatusBadRequest, codeEmptyMessage},
+		{"invalid json", http.MethodPost, "/echo", "application/json", `{"message":`, http.StatusBadRequest, codeInvalidJSON},
+		{"unknown field", http.MethodPost, "/echo", "application/json", `{"msg": "hi"}`, http.StatusBadRequest, codeInvalidJSON},
+		{"invalid mode", http.MethodPost, "/echo", "application/json", `{"message": "hi", "mode": "sideways"}`, http.StatusBadRequest, codeInvalidMode},
+		{"invalid hex", http.MethodPost, "/echo", "application/json", `{"message": "zz", "mode": "hexdecode"}`, http.StatusBadRequest, codeInvalidMessage},
+		{"negative delay", http.MethodPost, "/echo", "application/json", `{"message": "hi", "delay_ms": -1}`, http.StatusBadRequest, codeInvalidDelay},
+		{"wrong content type", http.MethodPost, "/echo", "text/plain", `hi`, http.StatusUnsupportedMediaType, codeUnsupportedMediaType},
+		{"missing file", http.MethodPost, "/echo/file", "multipart/form-data; boundary=x", "--x--\r\n", http.StatusBadRequest, codeMissingFile},
+		{"unknown greeting", http.MethodGet, "/greet/nope", "", "", http.StatusNotFound, codeNotFound},
+		{"unknown route", http.MethodGet, "/missing", "", "", http.StatusNotFound, codeNotFound},
+		{"wrong method", http.MethodDelete, "/echo", "", "", http.StatusMethodNotAllowed, codeMethodNotAllowed},
+		{"admin disabled", http.MethodPost, "/admin/reload", "", "", http.StatusForbidden, codeAdminDisabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			newTestServer().ServeHTTP(w, req)
+
+			assertError(t, w, tt.status, tt.code)
+		})
+	}
+}
+
+// TestRespondErrorUnexpected tests that errors other than apiError become an opaque 500
+func TestRespondErrorUnexpected(t *testing.T) {
+	server := newTestServer()
+	server.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	w := httptest.NewRecorder()
+
+	server.respondError(w, errors.New("database password is hunter2"))
+
+	if strings.Contains(w.Body.String(), "hunter2") {
+		t.Errorf("expected error details to stay out of the response, got %q", w.Body.String())
+	}
+	assertError(t, w, http.StatusInternalServerError, codeInternal)
+}
@@ -0,0 +1,78 @@
+package pingme
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// echoFields lists the JSON names of EchoData's fields, which ?fields= may select
+var echoFields = jsonFieldNames(reflect.TypeOf(EchoData{}))
+
+// jsonFieldNames returns the JSON keys a struct type encodes to, in field order
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if name, _, ok := jsonField(t.Field(i)); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// jsonField returns the key encoding/json gives a struct field and whether it
+// is omitempty; ok is false for fields it skips
+func jsonField(f reflect.StructField) (name string, omitEmpty, ok bool) {
+	if !f.IsExported() {
+		return "", false, false
+	}
+	name, options, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" && options == "" {
+		return "", false, false
+	}
+	if name == "" {
+		name = f.Name
+	}
+	for _, option := range strings.Split(options, ",") {
+		omitEmpty = omitEmpty || option == "omitempty"
+	}
+	return name, omitEmpty, true
+}
+
+// parseFields parses a comma-separated ?fields= value, returning nil when it
+// is empty and an error naming the first field EchoData doesn't have
+func parseFields(list string) ([]string, error) {
+	if list == "" {
+		return nil, nil
+	}
+	var fields []string
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if !slices.Contains(echoFields, field) {
+			return nil, newAPIError(http.StatusBadRequest, codeInvalidFields,
+				"Unknown field %q in fields; choose from %s", field, strings.Join(echoFields, ", "))
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// fieldSelection holds the EchoData fields picked by ?fields=, keyed by their
+// JSON names
+type fieldSelection map[string]interface{}
+
+// selectFields picks the named fields out of data. Optional fields that data
+// leaves out stay out.
+func selectFields(data EchoData, fields []string) fieldSelection {
+	v := reflect.ValueOf(data)
+	selected := make(fieldSelection, len(fields))
+	for i := 0; i < v.NumField(); i++ {
+		name, omitEmpty, ok := jsonField(v.Type().Field(i))
+		if !ok || !slices.Contains(fields, name) || (omitEmpty && isEmptyValue(v.Field(i))) {
+			continue
+		}
+		selected[name] = v.Field(i).Interface()
+	}
+	return selected
+}
@@ -0,0 +1,71 @@
+package pingme
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+// echoWithFields posts a message to /echo with the given ?fields= value
+func echoWithFields(body, fields string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/echo?fields="+url.QueryEscape(fields), bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	newTestServer().ServeHTTP(w, req)
+	return w
+}
+
+// TestEchoFields tests that ?fields= keeps only the named EchoData fields
+func TestEchoFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		fields   string
+		expected string
+	}{
+		{"subset", `{"message": "hello"}`, "original,length", `{"length":5,"original":"hello"}`},
+		{"spaces around names", `{"message": "hello"}`, "echoed, length", `{"echoed":"Echo: hello","length":5}`},
+		{"optional field present", `{"message": "aab", "stats": true}`, "unique_chars", `{"unique_chars":2}`},
+		{"optional field absent", `{"message": "hello"}`, "length,entropy", `{"length":5}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := echoWithFields(tt.body, tt.fields)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var response struct {
+				Data json.RawMessage `json:"data"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if string(response.Data) != tt.expected {
+				t.Errorf("expected data %s, got %s", tt.expected, response.Data)
+			}
+		})
+	}
+}
+
+// TestEchoFieldsUnknown tests that an unknown field name is rejected with 400
+func TestEchoFieldsUnknown(t *testing.T) {
+	for _, fields := range []string{"original,colour", "original,", "Original"} {
+		t.Run(fields, func(t *testing.T) {
+			assertError(t, echoWithFields(`{"message": "hello"}`, fields), http.StatusBadRequest, codeInvalidFields)
+		})
+	}
+}
+
+// TestEchoFieldNames tests that the selectable names follow EchoData's JSON tags
+func TestEchoFieldNames(t *testing.T) {
+	expected := []string{"original", "echoed", "length", "timestamp", "entropy", "unique_chars", "char_frequency", "redactions", "trimmed_chars", "normalization_changed", "processing_us"}
+	if !slices.Equal(echoFields, expected) {
+		t.Errorf("expected fields %v, got %v", expected, echoFields)
+	}
+}
@@ -0,0 +1,101 @@
+package pingme
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// fileField is the multipart form field /echo/file reads the upload from
+const fileField = "file"
+
+// FileEchoData represents the data returned by the file echo endpoint
+type FileEchoData struct {
+	Filename    string `json:"filename"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	ContentType string `json:"content_type"`
+}
+
+// sniffBuffer keeps the first bytes written to it for http.DetectContentType
+type sniffBuffer struct {
+	buf []byte
+}
+
+// Write keeps up to 512 bytes, the most DetectContentType considers
+func (b *sniffBuffer) Write(p []byte) (int, error) {
+	if room := 512 - len(b.buf); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		b.buf = append(b.buf, p[:room]...)
+	}
+	return len(p), nil
+}
+
+// fileEchoHandler handles POST /echo/file requests. The upload is streamed
+// through a hash and never stored, on disk or in memory.
+func (s *Server) fileEchoHandler(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		s.respondError(w, newAPIError(http.StatusUnsupportedMediaType, codeUnsupportedMediaType, "Content-Type must be multipart/form-data"))
+		return
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		s.respondError(w, newAPIError(http.StatusBadRequest, codeInvalidBody, "Invalid multipart body: %v", err))
+		return
+	}
+
+	maxFile := s.config().MaxFileBytes
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			s.respondBodyError(w, err, "Invalid multipart body")
+			return
+		}
+		if part.FormName() != fileField || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		// 0 leaves the file bounded only by MaxBodyBytes
+		var src io.Reader = part
+		if maxFile > 0 {
+			src = io.LimitReader(part, maxFile+1)
+		}
+		hash := sha256.New()
+		sniff := &sniffBuffer{}
+		size, err := io.Copy(io.MultiWriter(hash, sniff), src)
+		part.Close()
+		if err != nil {
+			s.respondBodyError(w, err, "Error reading file")
+			return
+		}
+		if maxFile > 0 && size > maxFile {
+			s.respondError(w, newAPIError(http.StatusRequestEntityTooLarge, codePayloadTooLarge, "File exceeds the maximum size of %d bytes", maxFile))
+			return
+		}
+
+		s.respondJSON(w, http.StatusOK, Response{
+			Success: true,
+			Message: "File processed successfully",
+			Data: FileEchoData{
+				Filename:    part.FileName(),
+				Size:        size,
+				SHA256:      hex.EncodeToString(hash.Sum(nil)),
+				ContentType: http.DetectContentType(sniff.buf),
+			},
+		})
+		return
+	}
+
+	s.respondError(w, newAPIError(http.StatusBadRequest, codeMissingFile, "Missing %q file field", fileField))
+}
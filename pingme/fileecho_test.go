@@ -0,0 +1,126 @@
+package pingme
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// postFile uploads content as the named file to /echo/file
+func postFile(t *testing.T, server *Server, filename string, content []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("failed to create form file: %v", err)
+	}
+	part.Write(content)
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/echo/file", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+// TestFileEcho tests that an uploaded file's metadata is returned
+func TestFileEcho(t *testing.T) {
+	content := []byte("<html><body>hi</body></html>")
+	w := postFile(t, newTestServer(), "page.html", content)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Success bool         `json:"success"`
+		Data    FileEchoData `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	sum := sha256.Sum256(content)
+	want := FileEchoData{
+		Filename:    "page.html",
+		Size:        int64(len(content)),
+		SHA256:      hex.EncodeToString(sum[:]),
+		ContentType: "text/html; charset=utf-8",
+	}
+	if response.Data != want {
+		t.Errorf("expected %+v, got %+v", want, response.Data)
+	}
+}
+
+// TestFileEchoTooLarge tests the file size and body size limits
+func TestFileEchoTooLarge(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     func(cfg *Config)
+		wantErr string
+	}{
+		{"file limit", func(cfg *Config) { cfg.MaxFileBytes = 10 }, "File exceeds"},
+		{"body limit", func(cfg *Config) { cfg.MaxBodyBytes = 100 }, "Request body exceeds"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.cfg(&cfg)
+			w := postFile(t, NewServer(cfg), "big.bin", bytes.Repeat([]byte("x"), 200))
+
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("expected status 413, got %d", w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %s", tt.wantErr, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestFileEchoUnlimited tests that MAX_FILE_BYTES=0 accepts any file within the body limit
+func TestFileEchoUnlimited(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxFileBytes = 0
+	content := bytes.Repeat([]byte("x"), 200)
+	w := postFile(t, NewServer(cfg), "big.bin", content)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Data FileEchoData `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Data.Size != int64(len(content)) {
+		t.Errorf("expected size %d, got %d", len(content), response.Data.Size)
+	}
+}
+
+// TestFileEchoMissingFile tests that a form without the file field is rejected
+func TestFileEchoMissingFile(t *testing.T) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("note", "no file here")
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/echo/file", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	newTestServer().ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
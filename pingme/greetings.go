@@ -0,0 +1,32 @@
+package pingme
+
+import "net/http"
+
+// namedGreetings maps the names accepted by /greet/{name} to their greeting text
+var namedGreetings = map[string]string{
+	"morning":   "Good morning from PingMe API!",
+	"afternoon": "Good afternoon from PingMe API!",
+	"evening":   "Good evening from PingMe API!",
+	"night":     "Good night from PingMe API!",
+}
+
+// namedGreetingHandler handles GET /greet/{name} requests
+func (s *Server) namedGreetingHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	greeting, ok := namedGreetings[name]
+	if !ok {
+		s.respondError(w, newAPIError(http.StatusNotFound, codeNotFound, "Unknown greeting %q", name))
+		return
+	}
+
+	data := GreetingData{
+		Greeting:  greeting,
+		Timestamp: s.now().UTC(),
+	}
+
+	s.respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Greeting retrieved successfully",
+		Data:    data,
+	})
+}
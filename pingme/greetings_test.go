@@ -0,0 +1,49 @@
+package pingme
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestNamedGreeting tests a known greeting name resolved through the mux
+func TestNamedGreeting(t *testing.T) {
+	w := httptest.NewRecorder()
+	newTestServer().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/greet/morning", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Success bool         `json:"success"`
+		Data    GreetingData `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if response.Data.Greeting != namedGreetings["morning"] {
+		t.Errorf("expected morning greeting, got %q", response.Data.Greeting)
+	}
+}
+
+// TestNamedGreetingUnknown tests that an unknown greeting name returns 404 JSON
+func TestNamedGreetingUnknown(t *testing.T) {
+	w := httptest.NewRecorder()
+	newTestServer().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/greet/midnight-snack", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+
+	var response Response
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if response.Success || response.Error == "" {
+		t.Errorf("expected an error response, got %+v", response)
+	}
+}
@@ -0,0 +1,68 @@
+package pingme
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	pingmev1 "github.com/Caleb125-source/pingme-api/gen/pingme/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcEchoService implements pingme.v1.EchoService with the validation and
+// transforms behind POST /echo
+type grpcEchoService struct {
+	pingmev1.UnimplementedEchoServiceServer
+	s *Server
+}
+
+// NewGRPCServer returns a gRPC server exposing the echo service, for
+// ENABLE_GRPC. The caller serves it on its own listener and stops it.
+func (s *Server) NewGRPCServer() *grpc.Server {
+	server := grpc.NewServer()
+	pingmev1.RegisterEchoServiceServer(server, &grpcEchoService{s: s})
+	return server
+}
+
+// Echo answers like POST /echo: the prefixed message, or the message
+// transformed by the request's mode
+func (g *grpcEchoService) Echo(_ context.Context, in *pingmev1.EchoRequest) (*pingmev1.EchoResponse, error) {
+	if g.s.config().MaintenanceMode {
+		return nil, status.Error(codes.Unavailable, "The API is down for maintenance. Try again later.")
+	}
+
+	req := EchoRequest{Message: in.GetMessage(), Mode: in.GetMode()}
+	transforms, err := g.s.validateEcho(req)
+	if err != nil {
+		return nil, g.s.grpcError(err)
+	}
+	data, err := g.s.buildEcho(req, transforms)
+	if err != nil {
+		return nil, g.s.grpcError(err)
+	}
+	return &pingmev1.EchoResponse{
+		Original: data.Original,
+		Echoed:   data.Echoed,
+		Length:   int32(data.Length),
+	}, nil
+}
+
+// grpcError converts an echo error to a gRPC status. Like respondError, it
+// logs errors other than apiError and hides their details.
+func (s *Server) grpcError(err error) error {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		s.logger.Error("Unexpected gRPC handler error", "error", err)
+		return status.Error(codes.Internal, "Internal server error")
+	}
+	switch apiErr.Status {
+	case http.StatusBadRequest:
+		return status.Error(codes.InvalidArgument, apiErr.Message)
+	case http.StatusServiceUnavailable:
+		return status.Error(codes.Unavailable, apiErr.Message)
+	default:
+		return status.Error(codes.Internal, apiErr.Message)
+	}
+}
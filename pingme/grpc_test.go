@@ -0,0 +1,104 @@
+package pingme
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	pingmev1 "github.com/Caleb125-source/pingme-api/gen/pingme/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newEchoClient serves server's gRPC API over an in-memory listener and
+// returns a client connected to it
+func newEchoClient(t *testing.T, server *Server) pingmev1.EchoServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := server.NewGRPCServer()
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pingmev1.NewEchoServiceClient(conn)
+}
+
+// TestGRPCEcho tests that the Echo RPC answers like POST /echo
+func TestGRPCEcho(t *testing.T) {
+	client := newEchoClient(t, newTestServer())
+
+	tests := []struct {
+		name     string
+		req      *pingmev1.EchoRequest
+		expected string
+	}{
+		{"default prefix", &pingmev1.EchoRequest{Message: "hello"}, "Echo: hello"},
+		{"mode", &pingmev1.EchoRequest{Message: "hello", Mode: "upper"}, "HELLO"},
+		{"chained modes", &pingmev1.EchoRequest{Message: "hi", Mode: "upper,hex"}, "4849"},
+		{"hexdecode", &pingmev1.EchoRequest{Message: "6869", Mode: "hexdecode"}, "hi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.Echo(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("Echo: %v", err)
+			}
+			if res.GetEchoed() != tt.expected {
+				t.Errorf("expected echoed %q, got %q", tt.expected, res.GetEchoed())
+			}
+			if res.GetOriginal() != tt.req.GetMessage() {
+				t.Errorf("expected original %q, got %q", tt.req.GetMessage(), res.GetOriginal())
+			}
+			if int(res.GetLength()) != len(tt.req.GetMessage()) {
+				t.Errorf("expected length %d, got %d", len(tt.req.GetMessage()), res.GetLength())
+			}
+		})
+	}
+}
+
+// TestGRPCEchoErrors tests that requests POST /echo rejects fail with the
+// matching gRPC status code
+func TestGRPCEchoErrors(t *testing.T) {
+	client := newEchoClient(t, newTestServer())
+
+	tests := []struct {
+		name string
+		req  *pingmev1.EchoRequest
+	}{
+		{"empty message", &pingmev1.EchoRequest{}},
+		{"unknown mode", &pingmev1.EchoRequest{Message: "hi", Mode: "sideways"}},
+		{"invalid hex", &pingmev1.EchoRequest{Message: "zz", Mode: "hexdecode"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.Echo(context.Background(), tt.req)
+			if code := status.Code(err); code != codes.InvalidArgument {
+				t.Errorf("expected %s, got %s (%v)", codes.InvalidArgument, code, err)
+			}
+		})
+	}
+}
+
+// TestGRPCEchoMaintenance tests that the Echo RPC is unavailable in maintenance mode
+func TestGRPCEchoMaintenance(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaintenanceMode = true
+	client := newEchoClient(t, NewServer(cfg))
+
+	_, err := client.Echo(context.Background(), &pingmev1.EchoRequest{Message: "hi"})
+	if code := status.Code(err); code != codes.Unavailable {
+		t.Errorf("expected %s, got %s (%v)", codes.Unavailable, code, err)
+	}
+}
@@ -0,0 +1,142 @@
+package pingme
+
+import (
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// compressibleTypes lists the media types worth gzipping; anything else,
+// such as images or application/octet-stream, is passed through untouched
+var compressibleTypes = map[string]bool{
+	"application/json":         true,
+	"application/problem+json": true,
+	"application/xml":          true,
+	"application/javascript":   true,
+	"application/x-ndjson":     true,
+	"image/svg+xml":            true,
+	"text/xml":                 true,
+}
+
+// compressible reports whether a Content-Type value is on the allowlist
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || compressibleTypes[mediaType]
+}
+
+// acceptsGzip reports whether the client listed gzip in Accept-Encoding
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(coding), "gzip") && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter decides when the headers are sent whether to compress the body
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+}
+
+// WriteHeader enables compression if the response type is on the allowlist
+func (w *gzipResponseWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	h := w.Header()
+	bodyless := statusCode == http.StatusNoContent || statusCode == http.StatusNotModified || statusCode < 200
+	// Content-Range counts bytes of the identity body, so a compressed slice would be unusable
+	partial := statusCode == http.StatusPartialContent
+	if !bodyless && !partial && h.Get("Content-Encoding") == "" && compressible(h.Get("Content-Type")) {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write compresses the body when enabled, sniffing the type if the handler didn't set one
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush pushes buffered compressed data through to the client
+func (w *gzipResponseWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Close finishes the gzip stream, if one was started
+func (w *gzipResponseWriter) Close() error {
+	if w.gz != nil {
+		return w.gz.Close()
+	}
+	return nil
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// gzipResponses compresses allowlisted response types for clients that accept gzip
+func (s *Server) gzipResponses(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer func() {
+			if err := gw.Close(); err != nil {
+				s.logWriteError("Error finishing gzip response", err)
+			}
+		}()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// decompressBody replaces a gzip-encoded r.Body with its decompressed stream,
+// capped at MaxBodyBytes after decompression so a small upload can't expand
+// without bound
+func (s *Server) decompressBody(w http.ResponseWriter, r *http.Request) error {
+	switch strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return nil
+	case "gzip":
+	default:
+		return newAPIError(http.StatusUnsupportedMediaType, codeUnsupportedMediaType, "Content-Encoding must be gzip or identity")
+	}
+
+	gz, err := gzip.NewReader(r.Body)
+	if err != nil {
+		return newAPIError(http.StatusBadRequest, codeInvalidBody, "Invalid gzip body: %v", err)
+	}
+	r.Body = gz
+	if limit := s.config().MaxBodyBytes; limit > 0 {
+		r.Body = http.MaxBytesReader(w, gz, limit)
+	}
+	return nil
+}
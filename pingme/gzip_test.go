@@ -0,0 +1,153 @@
+package pingme
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestGzipCompressesJSON tests that JSON responses are compressed for gzip clients
+func TestGzipCompressesJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("expected Content-Encoding gzip, got %q", got)
+	}
+
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("failed to open gzip body: %v", err)
+	}
+
+	var response Response
+	if err := json.NewDecoder(reader).Decode(&response); err != nil {
+		t.Fatalf("failed to decode decompressed response: %v", err)
+	}
+	if !response.Success {
+		t.Error("expected success to be true")
+	}
+}
+
+// TestGzipSkipsIncompressible tests that octet-stream responses pass through untouched
+func TestGzipSkipsIncompressible(t *testing.T) {
+	payload := []byte{0x00, 0x01, 0x02, 0xff}
+	handler := newTestServer().gzipResponses(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(payload)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/blob", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("expected no Content-Encoding, got %q", got)
+	}
+	body, _ := io.ReadAll(w.Body)
+	if !bytes.Equal(body, payload) {
+		t.Errorf("expected body to pass through unchanged, got %v", body)
+	}
+}
+
+// TestGzipRequiresAcceptEncoding tests that clients without gzip support get plain bodies
+func TestGzipRequiresAcceptEncoding(t *testing.T) {
+	tests := []string{"", "identity", "gzip;q=0"}
+
+	for _, acceptEncoding := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		w := httptest.NewRecorder()
+
+		newTestServer().ServeHTTP(w, req)
+
+		if got := w.Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("Accept-Encoding %q: expected no compression, got %q", acceptEncoding, got)
+		}
+	}
+}
+
+// gzipEcho posts a gzip-compressed body to /echo
+func gzipEcho(server *Server, body string) *httptest.ResponseRecorder {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(body))
+	gz.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", &compressed)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+// TestEchoGzipRequest tests that a gzip-compressed request body is echoed normally
+func TestEchoGzipRequest(t *testing.T) {
+	w := gzipEcho(newTestServer(), `{"message": "squeezed"}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Data EchoData `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Data.Echoed != "Echo: squeezed" {
+		t.Errorf("expected echoed %q, got %q", "Echo: squeezed", response.Data.Echoed)
+	}
+}
+
+// TestEchoGzipRequestLimit tests that MAX_BODY_BYTES applies after decompression
+func TestEchoGzipRequestLimit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxBodyBytes = 1024
+	cfg.MaxMessageLength = 0
+
+	// Highly repetitive, so it compresses far below the limit
+	body := `{"message": "` + strings.Repeat("a", 64<<10) + `"}`
+	w := gzipEcho(NewServer(cfg), body)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status 413, got %d", w.Code)
+	}
+}
+
+// TestEchoRequestEncodingErrors tests unsupported encodings and corrupt gzip bodies
+func TestEchoRequestEncodingErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		status   int
+	}{
+		{"unsupported", "br", http.StatusUnsupportedMediaType},
+		{"corrupt gzip", "gzip", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"message": "hi"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Content-Encoding", tt.encoding)
+			w := httptest.NewRecorder()
+
+			newTestServer().ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,565 @@
+package pingme
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// APIVersion is the version of the response envelope contract, sent on every
+// response so clients can detect breaking changes to the Response shape
+const APIVersion = "1"
+
+// Response represents the standard JSON response structure
+type Response struct {
+	Success   bool        `json:"success"`
+	Message   string      `json:"message,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	ErrorCode string      `json:"error_code,omitempty"`
+}
+
+// strictResponse mirrors Response but always emits every key, giving
+// clients a stable schema when Config.StrictEnvelope is set
+type strictResponse struct {
+	Success   bool        `json:"success"`
+	Message   string      `json:"message"`
+	Data      interface{} `json:"data"`
+	Error     string      `json:"error"`
+	ErrorCode string      `json:"error_code"`
+}
+
+// EchoRequest represents the expected JSON input for the echo endpoint
+type EchoRequest struct {
+	Message string `json:"message"`
+	Mode    string `json:"mode,omitempty"`
+	Stats   bool   `json:"stats,omitempty"`
+	DelayMs int    `json:"delay_ms,omitempty"`
+}
+
+// EchoData represents the data returned by the echo endpoint
+type EchoData struct {
+	Original  string    `json:"original"`
+	Echoed    string    `json:"echoed"`
+	Length    int       `json:"length"`
+	Timestamp time.Time `json:"timestamp"`
+
+	// Set only when the request asks for stats; pointers keep zero values visible
+	Entropy     *float64 `json:"entropy,omitempty"`
+	UniqueChars *int     `json:"unique_chars,omitempty"`
+	// CharFrequency maps each character to its number of occurrences
+	CharFrequency map[string]int `json:"char_frequency,omitempty"`
+
+	// Set only in mask mode: how many values mask redacted from the text it received
+	Redactions *int `json:"redactions,omitempty"`
+	// Set only in trim mode: how many whitespace characters trim removed from the text it received
+	TrimmedChars *int `json:"trimmed_chars,omitempty"`
+	// Set only in nfc and nfd modes: whether normalizing changed the bytes of the text it received
+	NormalizationChanged *bool `json:"normalization_changed,omitempty"`
+
+	// ProcessingMicros is the server-side time spent building this response,
+	// excluding reading the request and writing the reply
+	ProcessingMicros int64 `json:"processing_us"`
+}
+
+// ValidationData is returned by a dry-run /echo?validate=true request
+type ValidationData struct {
+	Valid bool `json:"valid"`
+}
+
+// GreetingData represents the data returned by the greeting endpoint
+type GreetingData struct {
+	Greeting  string    `json:"greeting"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// HealthData represents the data returned by the health check endpoint
+type HealthData struct {
+	Status string            `json:"status"`
+	Time   time.Time         `json:"time"`
+	Checks map[string]string `json:"checks,omitempty"`
+	// Details is set only for /healthz?verbose=true
+	Details []CheckResult `json:"details,omitempty"`
+}
+
+// CheckResult is one health check's outcome in a verbose /healthz response
+type CheckResult struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	Critical   bool    `json:"critical"`
+	DurationMs float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// envelope applies the configured envelope shape, key case and wrapper key to a response
+func (s *Server) envelope(response Response) interface{} {
+	cfg := s.config()
+	var body interface{} = response
+	if cfg.StrictEnvelope {
+		body = strictResponse(response)
+	}
+	body = s.applyJSONCase(body)
+	if cfg.ResponseWrapper != "" {
+		body = map[string]interface{}{cfg.ResponseWrapper: body}
+	}
+	return body
+}
+
+// applyJSONCase converts a response body's keys to camelCase when configured
+func (s *Server) applyJSONCase(body interface{}) interface{} {
+	if s.config().JSONCase == JSONCaseCamel {
+		body = camelCaseKeys(body)
+	}
+	return body
+}
+
+// respondJSON sends a JSON response with the specified status code.
+// Errors go out as problem details to clients that asked for them.
+func (s *Server) respondJSON(w http.ResponseWriter, statusCode int, response Response) {
+	if pw, ok := problemTarget(w); ok && statusCode >= 400 {
+		s.respondProblem(w, statusCode, pw.instance, response)
+		return
+	}
+
+	s.writeJSON(w, statusCode, s.envelope(response))
+}
+
+// writeJSON encodes body as the JSON response with the specified status
+// code, or as MessagePack for clients that asked for it
+func (s *Server) writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	if msgpackTarget(w) {
+		s.writeMsgpack(w, statusCode, body)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-API-Version", APIVersion)
+
+	w.WriteHeader(statusCode)
+	if err := s.encodeJSON(w, body); err != nil {
+		s.logWriteError("Error encoding JSON response", err)
+	}
+}
+
+// encodeJSON writes v as JSON. json.Encoder ends it with a newline, which
+// Config.CompactJSON drops for clients that compare bodies byte for byte.
+// With Config.PoolBuffers the body is built in a pooled buffer.
+func (s *Server) encodeJSON(w io.Writer, v interface{}) error {
+	cfg := s.config()
+	if !cfg.CompactJSON && !cfg.PoolBuffers {
+		return json.NewEncoder(w).Encode(v)
+	}
+	buf := getBuffer(cfg.PoolBuffers)
+	defer putBuffer(buf, cfg.PoolBuffers)
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	body := buf.Bytes()
+	if cfg.CompactJSON {
+		body = bytes.TrimSuffix(body, []byte("\n"))
+	}
+	_, err := w.Write(body)
+	return err
+}
+
+// greetingHandler handles GET / requests
+func (s *Server) greetingHandler(w http.ResponseWriter, r *http.Request) {
+	// Create greeting response
+	data := GreetingData{
+		Greeting:  s.config().Greeting,
+		Timestamp: s.now().UTC(),
+	}
+
+	s.respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Greeting retrieved successfully",
+		Data:    data,
+	})
+}
+
+// wantsUnwrapped reports whether the client asked, with ?raw=true or an
+// X-Raw-Response: true header, for echo data without the envelope
+func wantsUnwrapped(r *http.Request) bool {
+	if raw, _ := strconv.ParseBool(r.URL.Query().Get("raw")); raw {
+		return true
+	}
+	raw, _ := strconv.ParseBool(r.Header.Get("X-Raw-Response"))
+	return raw
+}
+
+// wantsPlainText reports whether the client asked for text/plain rather than JSON
+func wantsPlainText(r *http.Request) bool {
+	plain := false
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "application/json":
+			return false
+		case "text/plain":
+			plain = true
+		}
+	}
+	return plain
+}
+
+// respondBodyError reports a failure reading the request body, as 413 when
+// the body exceeded Config.MaxBodyBytes and 400 otherwise
+func (s *Server) respondBodyError(w http.ResponseWriter, err error, prefix string) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		s.respondError(w, bodyTooLarge(tooLarge))
+		return
+	}
+	s.respondError(w, newAPIError(http.StatusBadRequest, codeInvalidBody, "%s: %v", prefix, err))
+}
+
+// bodyTooLarge reports a request body cut off by http.MaxBytesReader
+func bodyTooLarge(err *http.MaxBytesError) *apiError {
+	return newAPIError(http.StatusRequestEntityTooLarge, codePayloadTooLarge,
+		"Request body exceeds the maximum size of %d bytes", err.Limit)
+}
+
+// respondText sends a plain-text response with the specified status code.
+// The text may reflect user input, so browsers are told not to sniff it as HTML.
+func (s *Server) respondText(w http.ResponseWriter, statusCode int, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(statusCode)
+	if _, err := fmt.Fprintln(w, text); err != nil {
+		s.logWriteError("Error writing text response", err)
+	}
+}
+
+// clientGone reports whether a write failed because the client disconnected
+func clientGone(err error) bool {
+	return errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, context.Canceled)
+}
+
+// logWriteError logs a failed response write. Disconnected clients are routine
+// and only logged at debug level so they don't drown out real failures.
+func (s *Server) logWriteError(msg string, err error) {
+	if clientGone(err) {
+		s.logger.Debug(msg, "error", err)
+		return
+	}
+	s.logger.Error(msg, "error", err)
+}
+
+// checkRawUTF8 rejects a JSON body holding invalid UTF-8 or lone surrogate
+// escapes such as "\ud800" when Config.StrictUTF8 is on. It must see the
+// raw bytes: encoding/json decodes both to U+FFFD, which would leave them
+// indistinguishable from a correctly encoded U+FFFD.
+func (s *Server) checkRawUTF8(raw []byte) error {
+	if s.config().StrictUTF8 && (!utf8.Valid(raw) || hasLoneSurrogate(raw)) {
+		return newAPIError(http.StatusBadRequest, codeInvalidUTF8, "Message must be valid UTF-8")
+	}
+	return nil
+}
+
+// hasLoneSurrogate reports whether raw JSON contains a \u escape for half
+// of a UTF-16 surrogate pair without its other half. Backslashes only occur
+// inside JSON strings, so no string tracking is needed.
+func hasLoneSurrogate(raw []byte) bool {
+	for i := 0; i < len(raw)-1; i++ {
+		if raw[i] != '\\' {
+			continue
+		}
+		if raw[i+1] != 'u' {
+			i++ // skip the escaped character, which may itself be a backslash
+			continue
+		}
+		r, ok := surrogateEscape(raw[i:])
+		switch {
+		case !ok:
+			i += 1
+		case utf16.IsSurrogate(r) && r >= 0xdc00:
+			return true
+		case utf16.IsSurrogate(r):
+			low, ok := surrogateEscape(raw[i+6:])
+			if !ok || low < 0xdc00 || !utf16.IsSurrogate(low) {
+				return true
+			}
+			i += 11
+		default:
+			i += 5
+		}
+	}
+	return false
+}
+
+// surrogateEscape parses a \uXXXX escape at the start of b
+func surrogateEscape(b []byte) (rune, bool) {
+	if len(b) < 6 || b[0] != '\\' || b[1] != 'u' {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(string(b[2:6]), 16, 16)
+	return rune(n), err == nil
+}
+
+// validateEcho checks an echo request and resolves its transforms before any work is done
+func (s *Server) validateEcho(req EchoRequest) ([]echoTransform, error) {
+	if req.Message == "" {
+		return nil, newAPIError(http.StatusBadRequest, codeEmptyMessage, "Message field cannot be empty")
+	}
+
+	// Query parameters keep their raw bytes; JSON bodies are checked by
+	// checkRawUTF8 before decoding turns bad input into U+FFFD
+	if s.config().StrictUTF8 && !utf8.ValidString(req.Message) {
+		return nil, newAPIError(http.StatusBadRequest, codeInvalidUTF8, "Message must be valid UTF-8")
+	}
+
+	// Limit length in runes rather than bytes so multibyte text gets the same allowance
+	if maxLength := s.config().MaxMessageLength; maxLength > 0 && utf8.RuneCountInString(req.Message) > maxLength {
+		return nil, newAPIError(http.StatusBadRequest, codeMessageTooLong, "Message exceeds the maximum length of %d characters", maxLength)
+	}
+
+	if req.Mode == "" {
+		return nil, nil
+	}
+	transforms, err := parseModes(req.Mode)
+	if err != nil {
+		return nil, newAPIError(http.StatusBadRequest, codeInvalidMode, "Invalid mode: %v", err)
+	}
+	// A message of only whitespace is as empty as "" once trimmed
+	if hasMode(req.Mode, "trim") && collapseWhitespace(req.Message) == "" {
+		return nil, newAPIError(http.StatusBadRequest, codeEmptyMessage, "Message field cannot be empty")
+	}
+	return transforms, nil
+}
+
+// buildEcho produces the echo response data for a validated request
+func (s *Server) buildEcho(req EchoRequest, transforms []echoTransform) (EchoData, error) {
+	// time.Since reads the monotonic clock, so the duration can't go negative
+	start := time.Now()
+	data := EchoData{
+		Original:  req.Message,
+		Echoed:    s.config().EchoPrefix + req.Message,
+		Length:    len(req.Message),
+		Timestamp: s.now().UTC(),
+	}
+
+	// A mode replaces the default echo with the transformed message
+	if transforms != nil {
+		echoed, stats, err := transformMessage(req.Message, transforms)
+		if err != nil {
+			return EchoData{}, err
+		}
+		data.Echoed = echoed
+		data.Redactions = stats.redactions
+		data.TrimmedChars = stats.trimmedChars
+		data.NormalizationChanged = stats.normalizationChanged
+	}
+
+	if req.Stats {
+		entropy, unique := messageStats(req.Message)
+		data.Entropy = &entropy
+		data.UniqueChars = &unique
+		data.CharFrequency = charFrequency(req.Message)
+	}
+	data.ProcessingMicros = time.Since(start).Microseconds()
+	return data, nil
+}
+
+// transformMessage runs the mode's transforms over message, answering 400
+// when the message isn't valid input for one of them, such as bad hex
+func transformMessage(message string, transforms []echoTransform) (string, transformStats, error) {
+	echoed, stats, err := applyTransforms(message, transforms)
+	if err != nil {
+		return "", transformStats{}, newAPIError(http.StatusBadRequest, codeInvalidMessage, "Invalid message for mode: %v", err)
+	}
+	return echoed, stats, nil
+}
+
+// echoFailer reports echo failures in the format the client asked for
+func (s *Server) echoFailer(w http.ResponseWriter, r *http.Request) func(error) {
+	plain := wantsPlainText(r)
+	return func(err error) {
+		var apiErr *apiError
+		if plain && errors.As(err, &apiErr) {
+			s.respondText(w, apiErr.Status, apiErr.Message)
+			return
+		}
+		s.respondError(w, err)
+	}
+}
+
+// echoHandler handles POST /echo requests
+func (s *Server) echoHandler(w http.ResponseWriter, r *http.Request) {
+	fail := s.echoFailer(w, r)
+
+	// Verify Content-Type is application/json
+	contentType := r.Header.Get("Content-Type")
+	if contentType != "application/json" {
+		fail(newAPIError(http.StatusUnsupportedMediaType, codeUnsupportedMediaType, "Content-Type must be application/json"))
+		return
+	}
+	if err := s.decompressBody(w, r); err != nil {
+		fail(err)
+		return
+	}
+
+	// Decode JSON request body with strict validation
+	pooled := s.config().PoolBuffers
+	req := getEchoRequest(pooled)
+	defer putEchoRequest(req, pooled)
+	body, logBody := s.teeBodyForLogging(r)
+	raw, err := io.ReadAll(body)
+	logBody()
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		fail(bodyTooLarge(tooLarge))
+		return
+	}
+	if err != nil {
+		fail(invalidEchoJSON(err))
+		return
+	}
+	if err := s.checkJSONShape(raw); err != nil {
+		fail(err)
+		return
+	}
+	if err := s.checkRawUTF8(raw); err != nil {
+		fail(err)
+		return
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields() // Reject unexpected fields
+	if err := decoder.Decode(req); err != nil {
+		fail(invalidEchoJSON(err))
+		return
+	}
+
+	s.respondEcho(w, r, *req, fail)
+}
+
+// invalidEchoJSON turns an echo request decoding error into a 400, singling
+// out a non-string message so clients see which field is wrong
+func invalidEchoJSON(err error) *apiError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field == "message" {
+		return newAPIError(http.StatusBadRequest, codeInvalidMessageType, "field 'message' must be a string")
+	}
+	return newAPIError(http.StatusBadRequest, codeInvalidJSON, "Invalid JSON: %v", err)
+}
+
+// echoQueryHandler handles GET /echo, reading the request from the message,
+// mode, stats and delay_ms query parameters
+func (s *Server) echoQueryHandler(w http.ResponseWriter, r *http.Request) {
+	fail := s.echoFailer(w, r)
+	query := r.URL.Query()
+	req := EchoRequest{Message: query.Get("message"), Mode: query.Get("mode")}
+	req.Stats, _ = strconv.ParseBool(query.Get("stats"))
+	if value := query.Get("delay_ms"); value != "" {
+		delay, err := strconv.Atoi(value)
+		if err != nil {
+			fail(newAPIError(http.StatusBadRequest, codeInvalidDelay, "delay_ms must be an integer, got %q", value))
+			return
+		}
+		req.DelayMs = delay
+	}
+	s.respondEcho(w, r, req, fail)
+}
+
+// respondEcho validates and answers a decoded echo request, so POST and GET
+// /echo share the same validation and error responses
+func (s *Server) respondEcho(w http.ResponseWriter, r *http.Request, req EchoRequest, fail func(error)) {
+	plain := wantsPlainText(r)
+	transforms, err := s.validateEcho(req)
+	if err != nil {
+		fail(err)
+		return
+	}
+	fields, err := parseFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	// Refuse delays the handler deadline would cut off rather than sending a truncated response
+	delay := time.Duration(req.DelayMs) * time.Millisecond
+	if req.DelayMs < 0 {
+		fail(newAPIError(http.StatusBadRequest, codeInvalidDelay, "delay_ms cannot be negative"))
+		return
+	}
+	if deadline, ok := r.Context().Deadline(); ok && delay > 0 && delay >= time.Until(deadline) {
+		fail(newAPIError(http.StatusBadRequest, codeInvalidDelay, "delay_ms of %d exceeds the remaining handler time of %dms",
+			req.DelayMs, time.Until(deadline).Milliseconds()))
+		return
+	}
+
+	// A dry run stops once validation passes, before any echo work. The
+	// transforms run too, since modes like hexdecode reject some messages.
+	if dryRun, _ := strconv.ParseBool(r.URL.Query().Get("validate")); dryRun {
+		if transforms != nil {
+			if _, _, err := transformMessage(req.Message, transforms); err != nil {
+				fail(err)
+				return
+			}
+		}
+		if plain {
+			s.respondText(w, http.StatusOK, "valid")
+			return
+		}
+		s.respondJSON(w, http.StatusOK, Response{
+			Success: true,
+			Message: "Validation passed",
+			Data:    ValidationData{Valid: true},
+		})
+		return
+	}
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			timer.Stop()
+			fail(newAPIError(http.StatusServiceUnavailable, codeTimeout, "Request timed out"))
+			return
+		}
+	}
+
+	data, err := s.buildEcho(req, transforms)
+	if err != nil {
+		fail(err)
+		return
+	}
+	status := s.recordEcho(w, r, data)
+
+	// Plain-text clients only want the echoed string
+	if plain {
+		s.respondText(w, status, data.Echoed)
+		return
+	}
+
+	var result interface{} = data
+	if fields != nil {
+		result = selectFields(data, fields)
+	}
+	if wantsUnwrapped(r) {
+		s.writeJSON(w, status, s.applyJSONCase(result))
+		return
+	}
+
+	s.respondJSON(w, status, Response{
+		Success: true,
+		Message: "Echo processed successfully",
+		Data:    result,
+	})
+}
@@ -0,0 +1,1126 @@
+package pingme
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// newTestServer returns a Server built from the default configuration
+func newTestServer() *Server {
+	return NewServer(DefaultConfig())
+}
+
+// TestGreetingHandler tests the GET / endpoint
+func TestGreetingHandler(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", res.StatusCode)
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	if contentType != "application/json" {
+		t.Errorf("expected Content-Type application/json, got %s", contentType)
+	}
+
+	var response Response
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if !response.Success {
+		t.Error("expected success to be true")
+	}
+
+	if response.Message == "" {
+		t.Error("expected non-empty message")
+	}
+
+	if response.Data == nil {
+		t.Fatal("expected data field to be present")
+	}
+
+	dataMap, ok := response.Data.(map[string]interface{})
+	if !ok {
+		t.Fatal("expected data to be a map")
+	}
+
+	if dataMap["greeting"] == nil {
+		t.Error("expected 'greeting' field in data")
+	}
+
+	if dataMap["timestamp"] == nil {
+		t.Error("expected 'timestamp' field in data")
+	}
+
+	if timestamp, ok := dataMap["timestamp"].(string); ok {
+		if _, err := time.Parse(time.RFC3339, timestamp); err != nil {
+			t.Errorf("invalid timestamp format: %v", err)
+		}
+	}
+}
+
+// TestGreetingHandlerWrongMethod tests wrong HTTP method on greeting endpoint
+func TestGreetingHandlerWrongMethod(t *testing.T) {
+	methods := []string{http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch}
+
+	for _, method := range methods {
+		t.Run(method, func(t *testing.T) {
+			req := httptest.NewRequest(method, "/", nil)
+			w := httptest.NewRecorder()
+
+			newTestServer().ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != http.StatusMethodNotAllowed {
+				t.Errorf("expected status 405 for method %s, got %d", method, res.StatusCode)
+			}
+
+			var response Response
+			if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			if response.Success {
+				t.Error("expected success to be false for wrong method")
+			}
+
+			if response.Error == "" {
+				t.Error("expected error message for wrong method")
+			}
+		})
+	}
+}
+
+// TestHealthHandler tests the GET /healthz endpoint
+func TestHealthHandler(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", res.StatusCode)
+	}
+
+	var response Response
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if !response.Success {
+		t.Error("expected success to be true")
+	}
+
+	if response.Data == nil {
+		t.Fatal("expected data field to be present")
+	}
+
+	dataMap, ok := response.Data.(map[string]interface{})
+	if !ok {
+		t.Fatal("expected data to be a map")
+	}
+
+	status, ok := dataMap["status"].(string)
+	if !ok || status != "healthy" {
+		t.Errorf("expected status 'healthy', got %v", dataMap["status"])
+	}
+}
+
+// TestHealthHandlerWrongMethod tests wrong HTTP method on health endpoint
+func TestHealthHandlerWrongMethod(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/healthz", nil)
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", res.StatusCode)
+	}
+}
+
+// TestEchoHandlerValidJSON tests POST /echo with valid JSON
+func TestEchoHandlerValidJSON(t *testing.T) {
+	payload := EchoRequest{Message: "Hello, World!"}
+	body, _ := json.Marshal(payload)
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", res.StatusCode)
+	}
+
+	var response Response
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if !response.Success {
+		t.Error("expected success to be true")
+	}
+
+	if response.Data == nil {
+		t.Fatal("expected data field to be present")
+	}
+
+	dataMap, ok := response.Data.(map[string]interface{})
+	if !ok {
+		t.Fatal("expected data to be a map")
+	}
+
+	if dataMap["original"] != "Hello, World!" {
+		t.Errorf("expected original message 'Hello, World!', got %v", dataMap["original"])
+	}
+
+	if expected := DefaultConfig().EchoPrefix + "Hello, World!"; dataMap["echoed"] != expected {
+		t.Errorf("expected echoed message %q, got %v", expected, dataMap["echoed"])
+	}
+
+	if length, ok := dataMap["length"].(float64); !ok || int(length) != 13 {
+		t.Errorf("expected length 13, got %v", dataMap["length"])
+	}
+}
+
+// TestEchoPrefix tests that ECHO_PREFIX replaces the default prefix, including an empty one
+func TestEchoPrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		expected string
+	}{
+		{"custom", ">> ", ">> Hello, World!"},
+		{"empty", "", "Hello, World!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ECHO_PREFIX", tt.prefix)
+			cfg := LoadConfig()
+			if cfg.EchoPrefix != tt.prefix {
+				t.Fatalf("expected EchoPrefix %q, got %q", tt.prefix, cfg.EchoPrefix)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(`{"message": "Hello, World!"}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			NewServer(cfg).ServeHTTP(w, req)
+
+			var response Response
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if echoed := echoedValue(t, response); echoed != tt.expected {
+				t.Errorf("expected echoed %q, got %q", tt.expected, echoed)
+			}
+		})
+	}
+}
+
+// TestEchoHandlerEmptyMessage tests validation for empty message
+func TestEchoHandlerEmptyMessage(t *testing.T) {
+	payload := EchoRequest{Message: ""}
+	body, _ := json.Marshal(payload)
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", res.StatusCode)
+	}
+
+	var response Response
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if response.Success {
+		t.Error("expected success to be false for empty message")
+	}
+
+	if response.Error == "" {
+		t.Error("expected error message for empty message")
+	}
+}
+
+// TestEchoHandlerInvalidJSON tests handling of malformed JSON
+func TestEchoHandlerInvalidJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString("{invalid json}"))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", res.StatusCode)
+	}
+
+	var response Response
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if response.Success {
+		t.Error("expected success to be false for invalid JSON")
+	}
+}
+
+// TestEchoHandlerNumericMessage tests that a non-string message gets its own error
+func TestEchoHandlerNumericMessage(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(`{"message": 123}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	if !strings.Contains(w.Body.String(), `"error":"field 'message' must be a string"`) {
+		t.Errorf("expected field error, got %s", w.Body.String())
+	}
+	assertError(t, w, http.StatusBadRequest, codeInvalidMessageType)
+}
+
+// TestEchoHandlerUnknownFields tests strict JSON validation
+func TestEchoHandlerUnknownFields(t *testing.T) {
+	invalidPayload := `{"message": "test", "extra": "field"}`
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(invalidPayload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", res.StatusCode)
+	}
+
+	var response Response
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if response.Success {
+		t.Error("expected success to be false for unknown fields")
+	}
+}
+
+// TestEchoHandlerWrongContentType tests Content-Type validation
+func TestEchoHandlerWrongContentType(t *testing.T) {
+	payload := EchoRequest{Message: "test"}
+	body, _ := json.Marshal(payload)
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "text/plain")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("expected status 415, got %d", res.StatusCode)
+	}
+
+	var response Response
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if response.Success {
+		t.Error("expected success to be false for wrong Content-Type")
+	}
+}
+
+// TestEchoHandlerWrongMethod tests wrong HTTP method
+func TestEchoHandlerWrongMethod(t *testing.T) {
+	methods := []string{http.MethodPut, http.MethodDelete, http.MethodPatch}
+
+	for _, method := range methods {
+		t.Run(method, func(t *testing.T) {
+			req := httptest.NewRequest(method, "/echo", nil)
+			w := httptest.NewRecorder()
+
+			newTestServer().ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != http.StatusMethodNotAllowed {
+				t.Errorf("expected status 405 for method %s, got %d", method, res.StatusCode)
+			}
+		})
+	}
+}
+
+// TestEchoHandlerEmptyBody tests handling of empty request body
+func TestEchoHandlerEmptyBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBuffer([]byte{}))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", res.StatusCode)
+	}
+}
+
+// TestRespondJSON tests the respondJSON helper function
+func TestRespondJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	response := Response{
+		Success: true,
+		Message: "Test message",
+		Data:    map[string]string{"key": "value"},
+	}
+
+	newTestServer().respondJSON(w, http.StatusOK, response)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", res.StatusCode)
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	if contentType != "application/json" {
+		t.Errorf("expected Content-Type application/json, got %s", contentType)
+	}
+
+	var decoded Response
+	if err := json.NewDecoder(res.Body).Decode(&decoded); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if decoded.Success != response.Success {
+		t.Errorf("expected success %v, got %v", response.Success, decoded.Success)
+	}
+}
+
+// brokenWriter simulates a ResponseWriter that fails on Write
+type brokenWriter struct {
+	httptest.ResponseRecorder
+}
+
+func (b *brokenWriter) Write(p []byte) (int, error) {
+	return 0, fmt.Errorf("simulated write error")
+}
+
+// TestRespondJSONEncodingError tests the error path in respondJSON
+func TestRespondJSONEncodingError(t *testing.T) {
+	w := &brokenWriter{*httptest.NewRecorder()}
+	response := Response{
+		Success: true,
+		Message: "test",
+	}
+	// Should not panic even when write fails
+	newTestServer().respondJSON(w, http.StatusOK, response)
+}
+
+// TestAPIVersionHeader tests that responses carry the envelope version header
+func TestAPIVersionHeader(t *testing.T) {
+	ts := httptest.NewServer(Handler())
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/")
+	if err != nil {
+		t.Fatalf("failed to GET /: %v", err)
+	}
+	defer res.Body.Close()
+	if got := res.Header.Get("X-API-Version"); got != APIVersion {
+		t.Errorf("expected X-API-Version %s from /, got %q", APIVersion, got)
+	}
+
+	res, err = http.Post(ts.URL+"/echo", "application/json", bytes.NewBufferString(`{"message": "test"}`))
+	if err != nil {
+		t.Fatalf("failed to POST /echo: %v", err)
+	}
+	defer res.Body.Close()
+	if got := res.Header.Get("X-API-Version"); got != APIVersion {
+		t.Errorf("expected X-API-Version %s from /echo, got %q", APIVersion, got)
+	}
+}
+
+// TestEchoHandlerPlainText tests that Accept: text/plain returns only the echoed string
+func TestEchoHandlerPlainText(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(`{"message": "hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/plain")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", res.StatusCode)
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "text/plain") {
+		t.Errorf("expected text/plain Content-Type, got %s", contentType)
+	}
+
+	if body := w.Body.String(); body != "Echo: hello\n" {
+		t.Errorf("expected body %q, got %q", "Echo: hello\n", body)
+	}
+}
+
+// TestEchoHandlerPlainTextEmptyMessage tests that plain-text errors keep their status
+func TestEchoHandlerPlainTextEmptyMessage(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(`{"message": ""}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/plain")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", res.StatusCode)
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "text/plain") {
+		t.Errorf("expected text/plain Content-Type, got %s", contentType)
+	}
+
+	if body := w.Body.String(); body != "Message field cannot be empty\n" {
+		t.Errorf("expected plain error message, got %q", body)
+	}
+}
+
+// TestEchoHandlerMaxMessageLength tests that messages are limited by rune count
+func TestEchoHandlerMaxMessageLength(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxMessageLength = 5
+
+	tests := []struct {
+		name       string
+		message    string
+		wantStatus int
+	}{
+		{"at limit", "hello", http.StatusOK},
+		{"multibyte at limit", "héllö", http.StatusOK},
+		{"over limit", "hello!", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(EchoRequest{Message: tt.message})
+			req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			NewServer(cfg).ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+
+			var response Response
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if tt.wantStatus == http.StatusBadRequest && !strings.Contains(response.Error, "5") {
+				t.Errorf("expected error to state the limit, got %q", response.Error)
+			}
+		})
+	}
+}
+
+// TestEchoHandlerPlainTextNoSniff tests that reflected plain text can't be sniffed as HTML
+func TestEchoHandlerPlainTextNoSniff(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(`{"message": "<script>alert(1)</script>"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/plain")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("expected X-Content-Type-Options nosniff, got %q", got)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("expected text/plain; charset=utf-8, got %q", got)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "<script>") {
+		t.Errorf("expected message to be echoed verbatim, got %q", body)
+	}
+}
+
+// TestEchoHandlerValidate tests the ?validate=true dry-run mode
+func TestEchoHandlerValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"valid", `{"message": "hello", "mode": "upper"}`, http.StatusOK},
+		{"invalid mode", `{"message": "hello", "mode": "sideways"}`, http.StatusBadRequest},
+		{"empty message", `{"message": ""}`, http.StatusBadRequest},
+		{"valid hex", `{"message": "6869", "mode": "hexdecode"}`, http.StatusOK},
+		{"invalid hex", `{"message": "zz", "mode": "hexdecode"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/echo?validate=true", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			newTestServer().ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+
+			var response struct {
+				Success bool            `json:"success"`
+				Error   string          `json:"error"`
+				Data    json.RawMessage `json:"data"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				if response.Error == "" {
+					t.Error("expected the validation issue in error")
+				}
+				return
+			}
+			if string(response.Data) != `{"valid":true}` {
+				t.Errorf("expected data {\"valid\":true}, got %s", response.Data)
+			}
+		})
+	}
+}
+
+// TestRespondJSONStrictEnvelope tests which envelope keys appear with and without STRICT_ENVELOPE
+func TestRespondJSONStrictEnvelope(t *testing.T) {
+	tests := []struct {
+		name     string
+		strict   bool
+		wantKeys []string
+		noKeys   []string
+	}{
+		{"default omits empty keys", false, []string{"success", "error"}, []string{"message", "data"}},
+		{"strict includes every key", true, []string{"success", "message", "data", "error"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StrictEnvelope = tt.strict
+			w := httptest.NewRecorder()
+
+			NewServer(cfg).respondJSON(w, http.StatusBadRequest, Response{Success: false, Error: "bad"})
+
+			var decoded map[string]json.RawMessage
+			if err := json.NewDecoder(w.Body).Decode(&decoded); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			for _, key := range tt.wantKeys {
+				if _, ok := decoded[key]; !ok {
+					t.Errorf("expected key %q in %v", key, decoded)
+				}
+			}
+			for _, key := range tt.noKeys {
+				if _, ok := decoded[key]; ok {
+					t.Errorf("expected key %q to be omitted", key)
+				}
+			}
+			if tt.strict && string(decoded["data"]) != "null" {
+				t.Errorf("expected data to be null, got %s", decoded["data"])
+			}
+		})
+	}
+}
+
+// TestCompactJSON tests that COMPACT_JSON drops the trailing newline, including from problem details
+func TestCompactJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		accept  string
+		compact bool
+	}{
+		{"default", "", false},
+		{"compact", "", true},
+		{"compact problem", problemContentType, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.CompactJSON = tt.compact
+			req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+
+			NewServer(cfg).ServeHTTP(w, req)
+
+			body := w.Body.String()
+			if hasNewline := strings.HasSuffix(body, "\n"); hasNewline == tt.compact {
+				t.Errorf("expected trailing newline %v, got body %q", !tt.compact, body)
+			}
+			if !json.Valid([]byte(body)) {
+				t.Errorf("expected valid JSON, got %q", body)
+			}
+		})
+	}
+}
+
+// TestEchoHandlerDelay tests that delay_ms is honoured within the handler timeout
+func TestEchoHandlerDelay(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HandlerTimeout = time.Second
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(`{"message": "hello", "delay_ms": 20}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	NewServer(cfg).ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("expected the response to be delayed by 20ms, took %v", elapsed)
+	}
+}
+
+// TestEchoHandlerDelayExceedsTimeout tests that a delay past the handler timeout fails fast with 400
+func TestEchoHandlerDelayExceedsTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HandlerTimeout = 50 * time.Millisecond
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(`{"message": "hello", "delay_ms": 1000}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	NewServer(cfg).ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+	if elapsed := time.Since(start); elapsed >= 50*time.Millisecond {
+		t.Errorf("expected an immediate rejection, took %v", elapsed)
+	}
+
+	var response Response
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !strings.Contains(response.Error, "delay_ms") {
+		t.Errorf("expected error to mention delay_ms, got %q", response.Error)
+	}
+}
+
+// TestEchoHandlerBodyTooLarge tests that bodies over MAX_BODY_BYTES get 413
+func TestEchoHandlerBodyTooLarge(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxBodyBytes = 16
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(`{"message": "this body is too long"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	NewServer(cfg).ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status 413, got %d", w.Code)
+	}
+}
+
+// TestSpoofedContentLength tests that a huge declared Content-Length with a
+// tiny body is answered normally without allocating for the declared size
+func TestSpoofedContentLength(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"echo", "/echo"},
+		{"raw echo", "/echo/raw"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer()
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(`{"message": "hi"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.ContentLength = 1 << 40
+			req.Header.Set("Content-Length", strconv.FormatInt(req.ContentLength, 10))
+			w := httptest.NewRecorder()
+
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			server.ServeHTTP(w, req)
+			runtime.ReadMemStats(&after)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+				t.Errorf("expected well under 1 MiB allocated, got %d bytes", allocated)
+			}
+		})
+	}
+}
+
+// failingWriter is a ResponseWriter whose body writes fail with err
+type failingWriter struct {
+	header http.Header
+	err    error
+}
+
+func (w *failingWriter) Header() http.Header       { return w.header }
+func (w *failingWriter) WriteHeader(int)           {}
+func (w *failingWriter) Write([]byte) (int, error) { return 0, w.err }
+
+// TestRespondJSONWriteErrors tests that disconnected clients are logged at
+// debug level while other write failures are still logged as errors
+func TestRespondJSONWriteErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		level string
+	}{
+		{"broken pipe", fmt.Errorf("write tcp: %w", syscall.EPIPE), "level=DEBUG"},
+		{"connection reset", fmt.Errorf("write tcp: %w", syscall.ECONNRESET), "level=DEBUG"},
+		{"other failure", errors.New("disk on fire"), "level=ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			server := newTestServer()
+			server.logger = slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+			server.respondJSON(&failingWriter{header: http.Header{}, err: tt.err}, http.StatusOK, Response{Success: true})
+
+			if !strings.Contains(logs.String(), tt.level) {
+				t.Errorf("expected a %s log line, got %q", tt.level, logs.String())
+			}
+			if tt.level == "level=DEBUG" && strings.Contains(logs.String(), "level=ERROR") {
+				t.Errorf("expected no error log, got %q", logs.String())
+			}
+		})
+	}
+}
+
+// TestEchoHandlerUnwrapped tests that ?raw=true and X-Raw-Response return EchoData at the top level
+func TestEchoHandlerUnwrapped(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		header string
+	}{
+		{"query", "/echo?raw=true", ""},
+		{"header", "/echo", "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.target, bytes.NewBufferString(`{"message": "hello"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.header != "" {
+				req.Header.Set("X-Raw-Response", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			newTestServer().ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", w.Code)
+			}
+			var body map[string]json.RawMessage
+			if err := json.NewDecoder(bytes.NewReader(w.Body.Bytes())).Decode(&body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if _, wrapped := body["success"]; wrapped {
+				t.Fatalf("expected no envelope, got %s", w.Body.String())
+			}
+
+			var data EchoData
+			if err := json.Unmarshal(w.Body.Bytes(), &data); err != nil {
+				t.Fatalf("failed to decode echo data: %v", err)
+			}
+			if data.Original != "hello" || data.Echoed != "Echo: hello" || data.Length != 5 {
+				t.Errorf("unexpected echo data %+v", data)
+			}
+		})
+	}
+}
+
+// TestEchoHandlerUnwrappedError tests that errors keep the envelope when raw output is requested
+func TestEchoHandlerUnwrappedError(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/echo?raw=true", bytes.NewBufferString(`{"message": ""}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	assertError(t, w, http.StatusBadRequest, codeEmptyMessage)
+}
+
+// TestEchoProcessingMicros tests that processing_us is reported and plausible for a heavy request
+func TestEchoProcessingMicros(t *testing.T) {
+	message := strings.Repeat("héllo wörld ", 800)
+	body, _ := json.Marshal(EchoRequest{Message: message, Mode: "upper,reverse,rot13,hex", Stats: true})
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	newTestServer().ServeHTTP(w, req)
+	elapsed := time.Since(start)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	raw, ok := response.Data["processing_us"]
+	if !ok {
+		t.Fatal("expected processing_us in the echo data")
+	}
+	micros, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		t.Fatalf("expected an integer processing_us, got %s", raw)
+	}
+	if micros < 0 || micros > elapsed.Microseconds() {
+		t.Errorf("expected processing_us between 0 and the %dµs round trip, got %d", elapsed.Microseconds(), micros)
+	}
+}
+
+// TestResponseWrapperHealthz tests that RESPONSE_WRAPPER nests the whole envelope, keeping omitempty inside
+func TestResponseWrapperHealthz(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ResponseWrapper = "result"
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	w := httptest.NewRecorder()
+
+	NewServer(cfg).ServeHTTP(w, req)
+
+	var wrapped map[string]map[string]json.RawMessage
+	if err := json.NewDecoder(w.Body).Decode(&wrapped); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(wrapped) != 1 {
+		t.Fatalf("expected only the wrapper key, got %v", wrapped)
+	}
+	inner, ok := wrapped["result"]
+	if !ok {
+		t.Fatalf("expected the envelope under \"result\", got %v", wrapped)
+	}
+	if string(inner["success"]) != "true" {
+		t.Errorf("expected success true, got %s", inner["success"])
+	}
+	if _, ok := inner["data"]; !ok {
+		t.Error("expected data inside the wrapper")
+	}
+	if _, ok := inner["error"]; ok {
+		t.Error("expected empty error to stay omitted inside the wrapper")
+	}
+}
+
+// TestEchoQueryMatchesPost tests that GET /echo without a message fails exactly like an empty POST
+func TestEchoQueryMatchesPost(t *testing.T) {
+	server := newTestServer()
+	decode := func(w *httptest.ResponseRecorder) Response {
+		t.Helper()
+		var response Response
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	getW := httptest.NewRecorder()
+	server.ServeHTTP(getW, httptest.NewRequest(http.MethodGet, "/echo", nil))
+	postReq := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(`{"message": ""}`))
+	postReq.Header.Set("Content-Type", "application/json")
+	postW := httptest.NewRecorder()
+	server.ServeHTTP(postW, postReq)
+
+	if getW.Code != postW.Code {
+		t.Errorf("expected matching status, got GET %d and POST %d", getW.Code, postW.Code)
+	}
+	getResponse, postResponse := decode(getW), decode(postW)
+	if !reflect.DeepEqual(getResponse, postResponse) {
+		t.Errorf("expected identical responses, got GET %+v and POST %+v", getResponse, postResponse)
+	}
+	if getResponse.ErrorCode != codeEmptyMessage {
+		t.Errorf("expected error code %q, got %q", codeEmptyMessage, getResponse.ErrorCode)
+	}
+}
+
+// TestEchoQuery tests that GET /echo reads the message, mode and stats from the query
+func TestEchoQuery(t *testing.T) {
+	w := httptest.NewRecorder()
+	newTestServer().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/echo?message=hello&mode=upper&stats=true", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var response struct {
+		Data EchoData `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Data.Echoed != "HELLO" {
+		t.Errorf("expected echoed %q, got %q", "HELLO", response.Data.Echoed)
+	}
+	if response.Data.UniqueChars == nil {
+		t.Error("expected stats in the response")
+	}
+
+	w = httptest.NewRecorder()
+	newTestServer().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/echo?message=hi&delay_ms=soon", nil))
+	assertError(t, w, http.StatusBadRequest, codeInvalidDelay)
+}
+
+// TestStrictUTF8 tests that STRICT_UTF8 rejects invalid UTF-8 that is otherwise echoed with U+FFFD
+func TestStrictUTF8(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+	}{
+		{"raw bytes", http.MethodPost, "/echo", "{\"message\": \"bad \xff byte\"}"},
+		{"lone surrogate", http.MethodPost, "/echo", `{"message": "bad \ud800 escape"}`},
+		{"lone low surrogate", http.MethodPost, "/echo", `{"message": "bad \udc00 escape"}`},
+		{"unpaired high surrogate", http.MethodPost, "/echo", `{"message": "bad \ud83d\u0041 pair"}`},
+		{"query", http.MethodGet, "/echo?message=bad%FFbyte", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, strict := range []bool{false, true} {
+				cfg := DefaultConfig()
+				cfg.StrictUTF8 = strict
+				req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+				req.Header.Set("Content-Type", "application/json")
+				w := httptest.NewRecorder()
+
+				NewServer(cfg).ServeHTTP(w, req)
+
+				if !strict {
+					if w.Code != http.StatusOK {
+						t.Errorf("expected status 200 when permissive, got %d", w.Code)
+					}
+					continue
+				}
+				assertError(t, w, http.StatusBadRequest, codeInvalidUTF8)
+			}
+		})
+	}
+}
+
+// TestStrictUTF8Accepts tests that STRICT_UTF8 lets through a real U+FFFD and valid escapes
+func TestStrictUTF8Accepts(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"raw replacement character", "{\"message\": \"a \uFFFD b\"}", "a \uFFFD b"},
+		{"escaped replacement character", `{"message": "a \ufffd b"}`, "a \uFFFD b"},
+		{"surrogate pair", `{"message": "\ud83d\ude00"}`, "\U0001F600"},
+		{"escaped backslash", `{"message": "\\ud800"}`, `\ud800`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StrictUTF8 = true
+			cfg.EchoPrefix = ""
+			w := postEchoBody(cfg, tt.body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var response Response
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if got := echoedValue(t, response); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// BenchmarkEchoHandler benchmarks the echo endpoint performance
+func BenchmarkEchoHandler(b *testing.B) {
+	payload := `{"message": "benchmark test"}`
+	server := newTestServer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/echo",
+			bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+	}
+}
@@ -0,0 +1,163 @@
+package pingme
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HealthChecker verifies a dependency; a nil error means it is healthy
+type HealthChecker interface {
+	Check(ctx context.Context) error
+}
+
+// HealthCheckFunc adapts an ordinary function to the HealthChecker interface
+type HealthCheckFunc func(ctx context.Context) error
+
+// Check calls f(ctx)
+func (f HealthCheckFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// namedCheck is a registered health check
+type namedCheck struct {
+	name     string
+	checker  HealthChecker
+	critical bool
+}
+
+// RegisterHealthCheck adds a critical dependency check that /healthz runs on
+// every request; if it fails the service is reported unhealthy with a 503
+func (s *Server) RegisterHealthCheck(name string, checker HealthChecker) {
+	s.addHealthCheck(namedCheck{name: name, checker: checker, critical: true})
+}
+
+// RegisterNonCriticalHealthCheck adds a check whose failure only marks the
+// service degraded, so /healthz keeps answering 200 and traffic keeps flowing
+func (s *Server) RegisterNonCriticalHealthCheck(name string, checker HealthChecker) {
+	s.addHealthCheck(namedCheck{name: name, checker: checker})
+}
+
+// addHealthCheck appends a check to the registry
+func (s *Server) addHealthCheck(check namedCheck) {
+	s.checksMu.Lock()
+	defer s.checksMu.Unlock()
+	s.checks = append(s.checks, check)
+}
+
+// healthResult is the outcome of the check at index in the checks snapshot
+type healthResult struct {
+	index    int
+	err      error
+	duration time.Duration
+}
+
+// healthReport summarises one run of the registered checks
+type healthReport struct {
+	// failures maps each failed check's name to its error
+	failures       map[string]string
+	criticalFailed bool
+	timedOut       bool
+	// details has every check's result in registration order
+	details []CheckResult
+}
+
+// runHealthChecks runs every registered check in parallel and reports the
+// failures keyed by check name, whether any failed check was critical, and
+// whether any check ran past HealthTimeout. Each check runs under
+// callWithTimeout, so one still running when the budget is spent is
+// reported as failed rather than waited on and a hung dependency can't
+// wedge the probe.
+func (s *Server) runHealthChecks(ctx context.Context) healthReport {
+	s.checksMu.RLock()
+	checks := append([]namedCheck(nil), s.checks...)
+	s.checksMu.RUnlock()
+
+	timeout := s.config().HealthTimeout
+	results := make(chan healthResult, len(checks))
+	for i, check := range checks {
+		go func() {
+			start := time.Now()
+			err := callWithTimeout(ctx, timeout, check.checker.Check)
+			results <- healthResult{index: i, err: err, duration: time.Since(start)}
+		}()
+	}
+
+	report := healthReport{
+		failures: make(map[string]string),
+		details:  make([]CheckResult, len(checks)),
+	}
+	for range checks {
+		result := <-results
+		check := checks[result.index]
+		detail := CheckResult{
+			Name:       check.name,
+			Status:     "ok",
+			Critical:   check.critical,
+			DurationMs: float64(result.duration.Microseconds()) / 1000,
+		}
+		if result.err != nil {
+			detail.Status = "failed"
+			detail.Error = result.err.Error()
+			report.failures[check.name] = detail.Error
+			report.criticalFailed = report.criticalFailed || check.critical
+			report.timedOut = report.timedOut || errorCode(result.err) == codeGatewayTimeout
+		}
+		report.details[result.index] = detail
+	}
+	return report
+}
+
+// healthHandler handles GET /healthz requests. ?verbose=true adds every
+// check's result and timing under details; the default stays minimal.
+func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
+	report := s.runHealthChecks(r.Context())
+	var details []CheckResult
+	if verbose, _ := strconv.ParseBool(r.URL.Query().Get("verbose")); verbose {
+		details = report.details
+	}
+
+	if report.criticalFailed {
+		failed := newAPIError(http.StatusServiceUnavailable, codeUnhealthy, "One or more health checks failed")
+		if report.timedOut {
+			failed = newAPIError(http.StatusGatewayTimeout, codeGatewayTimeout, "Health checks timed out")
+		}
+		failed.Data = HealthData{
+			Status:  "unhealthy",
+			Time:    s.now().UTC(),
+			Checks:  report.failures,
+			Details: details,
+		}
+		s.respondError(w, failed)
+		return
+	}
+
+	// Non-critical failures still serve traffic but are reported as degraded
+	if len(report.failures) > 0 {
+		s.respondJSON(w, http.StatusOK, Response{
+			Success: true,
+			Message: "Service is degraded",
+			Data: HealthData{
+				Status:  "degraded",
+				Time:    s.now().UTC(),
+				Checks:  report.failures,
+				Details: details,
+			},
+		})
+		return
+	}
+
+	// Return health status
+	data := HealthData{
+		Status:  "healthy",
+		Time:    s.now().UTC(),
+		Details: details,
+	}
+
+	s.respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Service is healthy",
+		Data:    data,
+	})
+}
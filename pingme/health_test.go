@@ -0,0 +1,229 @@
+package pingme
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHealthCheckFailing tests that a failing checker turns /healthz into a 503 with details
+func TestHealthCheckFailing(t *testing.T) {
+	server := newTestServer()
+	server.RegisterHealthCheck("datastore", HealthCheckFunc(func(context.Context) error {
+		return errors.New("connection refused")
+	}))
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+
+	var response struct {
+		Success bool       `json:"success"`
+		Data    HealthData `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if response.Success {
+		t.Error("expected success to be false")
+	}
+	if response.Data.Status != "unhealthy" {
+		t.Errorf("expected status unhealthy, got %q", response.Data.Status)
+	}
+	if got := response.Data.Checks["datastore"]; got != "connection refused" {
+		t.Errorf("expected datastore failure detail, got %q", got)
+	}
+	if _, ok := response.Data.Checks["self"]; ok {
+		t.Error("expected passing checks to be omitted from failures")
+	}
+}
+
+// TestHealthCheckPassing tests that passing checkers keep the default healthy response
+func TestHealthCheckPassing(t *testing.T) {
+	server := newTestServer()
+	server.RegisterHealthCheck("cache", HealthCheckFunc(func(context.Context) error { return nil }))
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+}
+
+// TestHealthCheckTimeout tests that a hung checker is cut off at HealthTimeout with a 504
+func TestHealthCheckTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HealthTimeout = 50 * time.Millisecond
+	server := NewServer(cfg)
+
+	// The checker ignores its context, like a dependency client with no deadline
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	server.RegisterHealthCheck("stuck", HealthCheckFunc(func(context.Context) error {
+		<-release
+		return nil
+	}))
+
+	w := httptest.NewRecorder()
+	start := time.Now()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected /healthz to answer within its budget, took %v", elapsed)
+	}
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected status 504, got %d", w.Code)
+	}
+
+	var response struct {
+		Error     string     `json:"error"`
+		ErrorCode string     `json:"error_code"`
+		Data      HealthData `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Error != "Health checks timed out" || response.ErrorCode != codeGatewayTimeout {
+		t.Errorf("expected gateway_timeout error, got %q (%q)", response.Error, response.ErrorCode)
+	}
+	if got := response.Data.Checks["stuck"]; !strings.Contains(got, "timed out") {
+		t.Errorf("expected stuck check to be reported as timed out, got %q", got)
+	}
+}
+
+// TestHealthStatusStates tests the healthy, degraded and unhealthy states
+func TestHealthStatusStates(t *testing.T) {
+	failing := HealthCheckFunc(func(context.Context) error { return errors.New("down") })
+	passing := HealthCheckFunc(func(context.Context) error { return nil })
+
+	tests := []struct {
+		name        string
+		register    func(s *Server)
+		wantCode    int
+		wantStatus  string
+		wantSuccess bool
+	}{
+		{
+			name: "healthy",
+			register: func(s *Server) {
+				s.RegisterHealthCheck("datastore", passing)
+				s.RegisterNonCriticalHealthCheck("cache", passing)
+			},
+			wantCode:    http.StatusOK,
+			wantStatus:  "healthy",
+			wantSuccess: true,
+		},
+		{
+			name: "degraded",
+			register: func(s *Server) {
+				s.RegisterHealthCheck("datastore", passing)
+				s.RegisterNonCriticalHealthCheck("cache", failing)
+			},
+			wantCode:    http.StatusOK,
+			wantStatus:  "degraded",
+			wantSuccess: true,
+		},
+		{
+			name: "unhealthy",
+			register: func(s *Server) {
+				s.RegisterHealthCheck("datastore", failing)
+				s.RegisterNonCriticalHealthCheck("cache", failing)
+			},
+			wantCode:    http.StatusServiceUnavailable,
+			wantStatus:  "unhealthy",
+			wantSuccess: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer()
+			tt.register(server)
+
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+			if w.Code != tt.wantCode {
+				t.Errorf("expected status %d, got %d", tt.wantCode, w.Code)
+			}
+
+			var response struct {
+				Success bool       `json:"success"`
+				Data    HealthData `json:"data"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Success != tt.wantSuccess {
+				t.Errorf("expected success %v, got %v", tt.wantSuccess, response.Success)
+			}
+			if response.Data.Status != tt.wantStatus {
+				t.Errorf("expected status %q, got %q", tt.wantStatus, response.Data.Status)
+			}
+		})
+	}
+}
+
+// TestHealthVerbose tests that ?verbose=true adds per-check details that the default response leaves out
+func TestHealthVerbose(t *testing.T) {
+	server := newTestServer()
+	server.RegisterNonCriticalHealthCheck("cache", HealthCheckFunc(func(context.Context) error {
+		time.Sleep(5 * time.Millisecond)
+		return errors.New("cache miss storm")
+	}))
+
+	decode := func(target string) map[string]json.RawMessage {
+		t.Helper()
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", target, w.Code)
+		}
+		var response struct {
+			Data map[string]json.RawMessage `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return response.Data
+	}
+
+	minimal := decode("/healthz")
+	if _, ok := minimal["details"]; ok {
+		t.Errorf("expected no details by default, got %s", minimal["details"])
+	}
+
+	verbose := decode("/healthz?verbose=true")
+	for key := range minimal {
+		if _, ok := verbose[key]; !ok {
+			t.Errorf("expected verbose response to keep %q", key)
+		}
+	}
+	var details []CheckResult
+	if err := json.Unmarshal(verbose["details"], &details); err != nil {
+		t.Fatalf("failed to decode details: %v", err)
+	}
+	if len(details) != 2 {
+		t.Fatalf("expected 2 check results, got %+v", details)
+	}
+	self, cache := details[0], details[1]
+	if self.Name != "self" || self.Status != "ok" || !self.Critical || self.Error != "" {
+		t.Errorf("expected a passing critical self check first, got %+v", self)
+	}
+	if cache.Name != "cache" || cache.Status != "failed" || cache.Critical || cache.Error != "cache miss storm" {
+		t.Errorf("expected a failed non-critical cache check, got %+v", cache)
+	}
+	if cache.DurationMs < 5 {
+		t.Errorf("expected the cache check to take at least 5ms, got %v", cache.DurationMs)
+	}
+}
@@ -0,0 +1,93 @@
+package pingme
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// HistoryEntry is a recorded echo, returned by GET /echo/history/{id}
+type HistoryEntry struct {
+	ID string `json:"id"`
+	EchoData
+}
+
+// echoHistory keeps the most recent echoes by id, evicting the oldest once
+// it holds size entries. GET /echo/history/{id} has no auth, so ids are
+// 128 random bits: only the client given the Location can find its echo.
+type echoHistory struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]HistoryEntry
+	order   []string
+}
+
+// newEchoHistory creates a history holding up to size echoes
+func newEchoHistory(size int) *echoHistory {
+	return &echoHistory{size: size, entries: make(map[string]HistoryEntry, size)}
+}
+
+// add records data under a new random id and returns it
+func (h *echoHistory) add(data EchoData) (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(b[:])
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.order) >= h.size {
+		delete(h.entries, h.order[0])
+		h.order = h.order[1:]
+	}
+	h.entries[id] = HistoryEntry{ID: id, EchoData: data}
+	h.order = append(h.order, id)
+	return id, nil
+}
+
+// get returns the echo recorded under id, if it hasn't been evicted
+func (h *echoHistory) get(id string) (HistoryEntry, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entry, ok := h.entries[id]
+	return entry, ok
+}
+
+// recordEcho stores data in the history and sets Location to the new
+// entry, returning the status to answer with: 201 when recorded, else 200
+func (s *Server) recordEcho(w http.ResponseWriter, r *http.Request, data EchoData) int {
+	if s.history == nil || r.Method != http.MethodPost {
+		return http.StatusOK
+	}
+	id, err := s.history.add(data)
+	if err != nil {
+		s.logger.Error("Failed to record echo history", "error", err)
+		return http.StatusOK
+	}
+	prefix := strings.TrimSuffix(s.config().PathPrefix, "/")
+	w.Header().Set("Location", prefix+"/echo/history/"+id)
+	return http.StatusCreated
+}
+
+// echoHistoryHandler handles GET /echo/history/{id}
+func (s *Server) echoHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var entry HistoryEntry
+	ok := false
+	if s.history != nil {
+		entry, ok = s.history.get(id)
+	}
+	if !ok {
+		s.respondError(w, newAPIError(http.StatusNotFound, codeNotFound, "No recorded echo %q", id))
+		return
+	}
+
+	s.respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Echo retrieved successfully",
+		Data:    entry,
+	})
+}
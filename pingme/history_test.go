@@ -0,0 +1,121 @@
+package pingme
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newHistoryServer returns a Server recording up to size echoes
+func newHistoryServer(size int) *Server {
+	cfg := DefaultConfig()
+	cfg.EchoHistorySize = size
+	return NewServer(cfg)
+}
+
+// getHistory fetches path from server
+func getHistory(server *Server, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+// TestEchoHistoryCreated tests that recorded echoes answer 201 with a Location that fetches them
+func TestEchoHistoryCreated(t *testing.T) {
+	server := newHistoryServer(10)
+
+	w := postEchoWithKey(server, "", "hello")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", w.Code)
+	}
+	location := w.Header().Get("Location")
+	id, ok := strings.CutPrefix(location, "/echo/history/")
+	if !ok || len(id) != 32 {
+		t.Fatalf("expected Location /echo/history/ and a 32-digit id, got %q", location)
+	}
+	var created Response
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	got := getHistory(server, location)
+	if got.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", got.Code)
+	}
+	var response struct {
+		Data HistoryEntry `json:"data"`
+	}
+	if err := json.Unmarshal(got.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Data.ID != id {
+		t.Errorf("expected id %q, got %q", id, response.Data.ID)
+	}
+	if want := echoedValue(t, created); response.Data.Echoed != want {
+		t.Errorf("expected echoed %q, got %q", want, response.Data.Echoed)
+	}
+
+	if next := postEchoWithKey(server, "", "again").Header().Get("Location"); next == location {
+		t.Errorf("expected a new Location for the next echo, got %q again", next)
+	}
+}
+
+// TestEchoHistoryDisabled tests that echoes answer 200 without Location by default
+func TestEchoHistoryDisabled(t *testing.T) {
+	server := newTestServer()
+
+	w := postEchoWithKey(server, "", "hello")
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("Location"); got != "" {
+		t.Errorf("expected no Location, got %q", got)
+	}
+	assertError(t, getHistory(server, "/echo/history/1"), http.StatusNotFound, codeNotFound)
+}
+
+// TestEchoHistoryEviction tests that only the latest entries are kept
+func TestEchoHistoryEviction(t *testing.T) {
+	server := newHistoryServer(2)
+	var locations []string
+	for _, message := range []string{"one", "two", "three"} {
+		locations = append(locations, postEchoWithKey(server, "", message).Header().Get("Location"))
+	}
+
+	assertError(t, getHistory(server, locations[0]), http.StatusNotFound, codeNotFound)
+	for _, location := range locations[1:] {
+		if w := getHistory(server, location); w.Code != http.StatusOK {
+			t.Errorf("expected %s to be kept, got status %d", location, w.Code)
+		}
+	}
+}
+
+// TestEchoHistoryUnguessable tests that ids can't be enumerated from small numbers
+func TestEchoHistoryUnguessable(t *testing.T) {
+	server := newHistoryServer(10)
+	postEchoWithKey(server, "", "private")
+
+	for _, id := range []string{"0", "1", "2", "unknown"} {
+		assertError(t, getHistory(server, "/echo/history/"+id), http.StatusNotFound, codeNotFound)
+	}
+}
+
+// TestEchoHistoryIdempotentReplay tests that a replay keeps the status and Location without recording again
+func TestEchoHistoryIdempotentReplay(t *testing.T) {
+	server := newHistoryServer(10)
+
+	first := postEchoWithKey(server, "abc", "hello")
+	second := postEchoWithKey(server, "abc", "hello")
+	if second.Code != http.StatusCreated {
+		t.Errorf("expected status 201, got %d", second.Code)
+	}
+	if got, want := second.Header().Get("Location"), first.Header().Get("Location"); got != want {
+		t.Errorf("expected Location %q, got %q", want, got)
+	}
+	if len(server.history.order) != 1 {
+		t.Errorf("expected one recorded echo, got %d", len(server.history.order))
+	}
+}
@@ -0,0 +1,256 @@
+package pingme
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// idempotencyCache remembers responses by Idempotency-Key for ttl, keeping
+// at most maxKeys entries in insertion order so memory stays bounded
+type idempotencyCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	maxKeys int
+	entries map[string]*list.Element
+	order   *list.List
+	now     func() time.Time
+}
+
+// cachedResponse is the first successful response sent for a key, along
+// with a fingerprint of the request that produced it. It is pending while
+// that request is still running.
+type cachedResponse struct {
+	key         string
+	fingerprint string
+	pending     bool
+	expires     time.Time
+	status      int
+	header      http.Header
+	body        []byte
+}
+
+// replayedHeaders are the response headers cached and replayed with the body
+var replayedHeaders = []string{"Content-Type", "Location", "X-API-Version"}
+
+// idempotencyState is what begin found for a key
+type idempotencyState int
+
+const (
+	// idempotencyNew means the caller owns the key and should run the request
+	idempotencyNew idempotencyState = iota
+	// idempotencyReplay means a finished response is cached for the same request
+	idempotencyReplay
+	// idempotencyPending means the same request is still running
+	idempotencyPending
+	// idempotencyMismatch means the key was used for a different request
+	idempotencyMismatch
+)
+
+// newIdempotencyCache creates a cache holding up to maxKeys responses for ttl each
+func newIdempotencyCache(ttl time.Duration, maxKeys int) *idempotencyCache {
+	return &idempotencyCache{
+		ttl:     ttl,
+		maxKeys: maxKeys,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+// begin looks up key, dropping it if it has expired. When the key is free
+// it is claimed with a pending entry, so concurrent duplicates see
+// idempotencyPending rather than running the request a second time; the
+// caller must then finish or abandon the returned entry.
+func (c *idempotencyCache) begin(key, fingerprint string) (*cachedResponse, idempotencyState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		cached := elem.Value.(*cachedResponse)
+		switch {
+		case !c.now().Before(cached.expires):
+			c.order.Remove(elem)
+			delete(c.entries, key)
+		case cached.fingerprint != fingerprint:
+			return nil, idempotencyMismatch
+		case cached.pending:
+			return nil, idempotencyPending
+		default:
+			return cached, idempotencyReplay
+		}
+	}
+
+	if c.maxKeys > 0 && len(c.entries) >= c.maxKeys {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedResponse).key)
+	}
+	cached := &cachedResponse{key: key, fingerprint: fingerprint, pending: true, expires: c.now().Add(c.ttl)}
+	c.entries[key] = c.order.PushFront(cached)
+	return cached, idempotencyNew
+}
+
+// finish stores the response for an entry claimed by begin, starting its
+// ttl; of header it keeps the replayedHeaders
+func (c *idempotencyCache) finish(cached *cachedResponse, status int, header http.Header, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached.header = make(http.Header, len(replayedHeaders))
+	for _, name := range replayedHeaders {
+		if value := header.Get(name); value != "" {
+			cached.header.Set(name, value)
+		}
+	}
+	cached.status, cached.body = status, body
+	cached.expires = c.now().Add(c.ttl)
+	cached.pending = false
+}
+
+// abandon releases an entry claimed by begin so the key can be retried
+func (c *idempotencyCache) abandon(cached *cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[cached.key]; ok && elem.Value == cached {
+		c.order.Remove(elem)
+		delete(c.entries, cached.key)
+	}
+}
+
+// requestFingerprint hashes what makes a request distinct, so a reused key
+// can be told apart from a genuine retry. That includes the response format
+// the client negotiated, so a retry asking for another format isn't
+// replayed a body it can't read.
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n%s\n%s\n", r.Method, r.URL.Path, r.URL.RawQuery, responseFormat(r))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseFormat names the body format a successful response to r takes,
+// following the same negotiation as the handlers and writeJSON
+func responseFormat(r *http.Request) string {
+	format := "application/json"
+	switch {
+	case wantsPlainText(r):
+		return "text/plain"
+	case wantsMsgpack(r):
+		format = msgpackContentType
+	}
+	if wantsUnwrapped(r) {
+		format += " unwrapped"
+	}
+	return format
+}
+
+// idempotencyRecorder copies a response as it is written so it can be cached
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *idempotencyRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *idempotencyRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (r *idempotencyRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// idempotent replays the first successful response for a client's
+// Idempotency-Key instead of running the handler again. Keys are scoped to
+// the client IP, which keeps unrelated clients from colliding but is no
+// security boundary: clients behind one NAT or an untrusted proxy share an
+// IP, so keys should be unguessable. Reusing a key for a different method,
+// path, query, body or response format answers 422, a duplicate arriving while the first is
+// still running answers 409, and failed requests and ?validate=true dry
+// runs aren't cached so they can be retried.
+func (s *Server) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		dryRun, _ := strconv.ParseBool(r.URL.Query().Get("validate"))
+		if key == "" || s.idempotency == nil || dryRun {
+			next(w, r)
+			return
+		}
+		key = s.clientIP(r) + " " + key
+
+		// Buffer the body to fingerprint it; a read error such as an
+		// oversized body is left for the handler to report
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+			next(w, r)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		cached, state := s.idempotency.begin(key, requestFingerprint(r, body))
+		switch state {
+		case idempotencyMismatch:
+			s.respondError(w, newAPIError(http.StatusUnprocessableEntity, codeIdempotencyKeyReused,
+				"Idempotency-Key was already used for a different request"))
+			return
+		case idempotencyPending:
+			s.respondError(w, newAPIError(http.StatusConflict, codeIdempotencyInProgress,
+				"A request with this Idempotency-Key is still in progress"))
+			return
+		case idempotencyReplay:
+			for name := range cached.header {
+				w.Header().Set(name, cached.header.Get(name))
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(cached.status)
+			if _, err := w.Write(cached.body); err != nil {
+				s.logWriteError("Error replaying idempotent response", err)
+			}
+			return
+		}
+
+		// Release the key unless a success was cached, even if the handler panics
+		finished := false
+		defer func() {
+			if !finished {
+				s.idempotency.abandon(cached)
+			}
+		}()
+		rec := &idempotencyRecorder{ResponseWriter: w}
+		next(rec, r)
+		if rec.status >= 200 && rec.status < 300 {
+			s.idempotency.finish(cached, rec.status, w.Header(), rec.body.Bytes())
+			finished = true
+		}
+	}
+}
+
+// errReader replays a read error after a buffered prefix
+type errReader struct {
+	err error
+}
+
+func (e errReader) Read([]byte) (int, error) {
+	return 0, e.err
+}
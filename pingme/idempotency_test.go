@@ -0,0 +1,184 @@
+package pingme
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// postEchoWithKey sends an echo request carrying an Idempotency-Key
+func postEchoWithKey(server *Server, key, message string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(`{"message": "`+message+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+// TestIdempotencyKeyReplay tests that a repeated key replays the first response
+func TestIdempotencyKeyReplay(t *testing.T) {
+	server := newTestServer()
+
+	first := postEchoWithKey(server, "abc", "first")
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", first.Code)
+	}
+	if got := first.Header().Get("Idempotent-Replayed"); got != "" {
+		t.Errorf("expected first response not to be a replay, got %q", got)
+	}
+
+	second := postEchoWithKey(server, "abc", "first")
+	if second.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", second.Code)
+	}
+	if got := second.Header().Get("Idempotent-Replayed"); got != "true" {
+		t.Errorf("expected Idempotent-Replayed true, got %q", got)
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Errorf("expected cached body %q, got %q", first.Body.String(), second.Body.String())
+	}
+	if got := second.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("expected Content-Type application/json, got %q", got)
+	}
+	if got := second.Header().Get("X-API-Version"); got != APIVersion {
+		t.Errorf("expected X-API-Version %s, got %q", APIVersion, got)
+	}
+
+	other := postEchoWithKey(server, "xyz", "second")
+	if other.Body.String() == first.Body.String() {
+		t.Error("expected a different key to run the handler")
+	}
+}
+
+// TestIdempotencyKeyExpiry tests that keys are forgotten after IdempotencyTTL
+func TestIdempotencyKeyExpiry(t *testing.T) {
+	server := newTestServer()
+	now := time.Now()
+	server.idempotency.now = func() time.Time { return now }
+
+	first := postEchoWithKey(server, "abc", "first")
+
+	now = now.Add(server.config().IdempotencyTTL)
+	second := postEchoWithKey(server, "abc", "second")
+	if got := second.Header().Get("Idempotent-Replayed"); got != "" {
+		t.Errorf("expected an expired key not to replay, got %q", got)
+	}
+	if second.Body.String() == first.Body.String() {
+		t.Error("expected an expired key to run the handler again")
+	}
+}
+
+// TestIdempotencyKeyFailuresNotCached tests that failed requests can be retried
+func TestIdempotencyKeyFailuresNotCached(t *testing.T) {
+	server := newTestServer()
+
+	if w := postEchoWithKey(server, "abc", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+	if w := postEchoWithKey(server, "abc", "fixed"); w.Code != http.StatusOK {
+		t.Errorf("expected the retry to succeed, got %d", w.Code)
+	}
+}
+
+// TestIdempotencyKeyReused tests that a key sent with a different request, or
+// asking for a different response format, is rejected
+func TestIdempotencyKeyReused(t *testing.T) {
+	server := newTestServer()
+
+	postEchoWithKey(server, "abc", "first")
+	assertError(t, postEchoWithKey(server, "abc", "second"), http.StatusUnprocessableEntity, codeIdempotencyKeyReused)
+
+	req := httptest.NewRequest(http.MethodPost, "/echo?mode=upper", bytes.NewBufferString(`{"message": "first"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "abc")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	assertError(t, w, http.StatusUnprocessableEntity, codeIdempotencyKeyReused)
+
+	// A MessagePack client mustn't be replayed the cached JSON body
+	req = httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(`{"message": "first"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/msgpack")
+	req.Header.Set("Idempotency-Key", "abc")
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422 for a different response format, got %d", w.Code)
+	}
+}
+
+// TestIdempotencyKeyDryRunNotCached tests that ?validate=true doesn't claim the key
+func TestIdempotencyKeyDryRunNotCached(t *testing.T) {
+	server := newTestServer()
+
+	req := httptest.NewRequest(http.MethodPost, "/echo?validate=true", bytes.NewBufferString(`{"message": "first"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "abc")
+	server.ServeHTTP(httptest.NewRecorder(), req)
+
+	w := postEchoWithKey(server, "abc", "first")
+	if got := w.Header().Get("Idempotent-Replayed"); got != "" {
+		t.Errorf("expected the real request not to replay the dry run, got %q", got)
+	}
+	if strings.Contains(w.Body.String(), `"valid"`) {
+		t.Errorf("expected an echo, got %s", w.Body.String())
+	}
+}
+
+// TestIdempotencyKeyInProgress tests that a duplicate waiting on the first request isn't run
+func TestIdempotencyKeyInProgress(t *testing.T) {
+	server := newTestServer()
+	started, release := make(chan struct{}), make(chan struct{})
+	calls := 0
+	handler := server.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	})
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(`{"message": "hi"}`))
+		req.Header.Set("Idempotency-Key", "abc")
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- send() }()
+	<-started
+	assertError(t, send(), http.StatusConflict, codeIdempotencyInProgress)
+	close(release)
+	if w := <-done; w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", w.Code)
+	}
+
+	if w := send(); w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("expected a replayed 201, got %d", w.Code)
+	}
+	if calls != 1 {
+		t.Errorf("expected the handler to run once, ran %d times", calls)
+	}
+}
+
+// TestIdempotencyCacheBounded tests that the oldest key is evicted when the cache is full
+func TestIdempotencyCacheBounded(t *testing.T) {
+	cache := newIdempotencyCache(time.Minute, 2)
+	for _, key := range []string{"a", "b", "c"} {
+		cached, _ := cache.begin(key, "")
+		cache.finish(cached, http.StatusOK, nil, nil)
+	}
+
+	for _, key := range []string{"b", "c"} {
+		if _, state := cache.begin(key, ""); state != idempotencyReplay {
+			t.Errorf("expected key %q to be cached", key)
+		}
+	}
+	if _, state := cache.begin("a", ""); state != idempotencyNew {
+		t.Error("expected the oldest key to be evicted")
+	}
+}
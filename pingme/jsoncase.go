@@ -0,0 +1,110 @@
+package pingme
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// jsonMarshalerType identifies values, such as time.Time, that encode themselves
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// camelCaseKeys rebuilds v with every struct field keyed by its JSON name in
+// camelCase. Map keys are data, such as header and health check names, and
+// stay as they are, except in a fieldSelection, whose keys are field names.
+func camelCaseKeys(v interface{}) interface{} {
+	return camelCaseValue(reflect.ValueOf(v))
+}
+
+// camelCaseValue converts one value for camelCaseKeys, following
+// encoding/json's rules for tags, omitempty and nil values
+func camelCaseValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Type().Implements(jsonMarshalerType) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return camelCaseValue(v.Elem())
+	case reflect.Struct:
+		t := v.Type()
+		converted := make(map[string]interface{}, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			name, omitEmpty, ok := jsonField(t.Field(i))
+			if !ok || (omitEmpty && isEmptyValue(v.Field(i))) {
+				continue
+			}
+			converted[snakeToCamel(name)] = camelCaseValue(v.Field(i))
+		}
+		return converted
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		_, fieldNames := v.Interface().(fieldSelection)
+		converted := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			if fieldNames {
+				key = snakeToCamel(key)
+			}
+			converted[key] = camelCaseValue(iter.Value())
+		}
+		return converted
+	case reflect.Slice:
+		if v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		converted := make([]interface{}, v.Len())
+		for i := range converted {
+			converted[i] = camelCaseValue(v.Index(i))
+		}
+		return converted
+	default:
+		return v.Interface()
+	}
+}
+
+// isEmptyValue reports whether omitempty leaves v out, as encoding/json decides it
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+// snakeToCamel converts a snake_case key such as "error_code" to "errorCode"
+func snakeToCamel(key string) string {
+	var b strings.Builder
+	upperNext := false
+	for i, r := range key {
+		switch {
+		case r == '_' && i > 0 && i < len(key)-1:
+			upperNext = true
+		case upperNext:
+			b.WriteString(strings.ToUpper(string(r)))
+			upperNext = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
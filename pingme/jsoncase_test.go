@@ -0,0 +1,150 @@
+package pingme
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestJSONCaseKeys tests response key names under both JSON_CASE settings
+func TestJSONCaseKeys(t *testing.T) {
+	tests := []struct {
+		jsonCase string
+		present  string
+		absent   string
+	}{
+		{JSONCaseSnake, "error_code", "errorCode"},
+		{JSONCaseCamel, "errorCode", "error_code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.jsonCase, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.JSONCase = tt.jsonCase
+			w := httptest.NewRecorder()
+
+			NewServer(cfg).respondJSON(w, http.StatusOK, Response{
+				Success: true,
+				Data: struct {
+					ErrorCode string `json:"error_code"`
+				}{"none"},
+			})
+
+			var decoded struct {
+				Data map[string]string `json:"data"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&decoded); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			if _, ok := decoded.Data[tt.present]; !ok {
+				t.Errorf("expected key %q in data, got %v", tt.present, decoded.Data)
+			}
+			if _, ok := decoded.Data[tt.absent]; ok {
+				t.Errorf("expected no key %q in data, got %v", tt.absent, decoded.Data)
+			}
+		})
+	}
+}
+
+// TestJSONCaseCamelEcho tests that camelCase applies to the echo response fields
+func TestJSONCaseCamelEcho(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.JSONCase = JSONCaseCamel
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(`{"message": "hi"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	NewServer(cfg).ServeHTTP(w, req)
+
+	var decoded map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&decoded); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	dataMap, ok := decoded["data"].(map[string]interface{})
+	if !ok {
+		t.Fatal("expected data to be a map")
+	}
+
+	for _, key := range []string{"original", "echoed", "length", "timestamp"} {
+		if _, ok := dataMap[key]; !ok {
+			t.Errorf("expected key %q in data", key)
+		}
+	}
+	if length, ok := dataMap["length"].(float64); !ok || length != 2 {
+		t.Errorf("expected length 2, got %v", dataMap["length"])
+	}
+}
+
+// TestJSONCaseCamelKeepsMapKeys tests that camelCase renames struct fields,
+// including ones picked with ?fields=, but leaves map keys such as header and
+// health check names alone
+func TestJSONCaseCamelKeepsMapKeys(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.JSONCase = JSONCaseCamel
+	server := NewServer(cfg)
+	server.RegisterNonCriticalHealthCheck("primary_db", HealthCheckFunc(func(context.Context) error {
+		return errors.New("down")
+	}))
+
+	decode := func(req *http.Request) map[string]interface{} {
+		t.Helper()
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		var decoded map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&decoded); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		data, ok := decoded["data"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("expected data to be a map, got %v", decoded)
+		}
+		return data
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/echo/raw", nil)
+	req.Header["X-Foo_Bar"] = []string{"kept"}
+	headers, _ := decode(req)["headers"].(map[string]interface{})
+	if _, ok := headers["X-Foo_Bar"]; !ok {
+		t.Errorf("expected header X-Foo_Bar unchanged, got %v", headers)
+	}
+
+	checks, _ := decode(httptest.NewRequest(http.MethodGet, "/healthz", nil))["checks"].(map[string]interface{})
+	if _, ok := checks["primary_db"]; !ok {
+		t.Errorf("expected check primary_db unchanged, got %v", checks)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/echo?fields=unique_chars,char_frequency", bytes.NewBufferString(`{"message": "a_b", "stats": true}`))
+	req.Header.Set("Content-Type", "application/json")
+	data := decode(req)
+	if _, ok := data["uniqueChars"]; !ok {
+		t.Errorf("expected selected field uniqueChars, got %v", data)
+	}
+	frequency, _ := data["charFrequency"].(map[string]interface{})
+	if frequency["_"] != float64(1) {
+		t.Errorf("expected char_frequency keys unchanged, got %v", data["charFrequency"])
+	}
+}
+
+// TestSnakeToCamel tests key conversion edge cases
+func TestSnakeToCamel(t *testing.T) {
+	tests := map[string]string{
+		"error_code":       "errorCode",
+		"success":          "success",
+		"unique_chars_len": "uniqueCharsLen",
+		"_":                "_",
+		"trailing_":        "trailing_",
+	}
+
+	for input, expected := range tests {
+		if got := snakeToCamel(input); got != expected {
+			t.Errorf("snakeToCamel(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package pingme
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+// checkJSONShape walks raw token by token, without building any values, and
+// rejects it if objects and arrays nest deeper than Config.MaxJSONDepth or
+// it holds more than Config.MaxJSONTokens tokens. Keys, values and each
+// opening or closing bracket count as one token. Malformed JSON passes, so
+// the decoder that runs next reports the syntax error as usual.
+func (s *Server) checkJSONShape(raw []byte) error {
+	cfg := s.config()
+	maxDepth, maxTokens := cfg.MaxJSONDepth, cfg.MaxJSONTokens
+	if maxDepth <= 0 && maxTokens <= 0 {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	depth, tokens := 0, 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil
+		}
+		tokens++
+		if maxTokens > 0 && tokens > maxTokens {
+			return newAPIError(http.StatusBadRequest, codeJSONTooComplex, "JSON has more than %d tokens", maxTokens)
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+			if maxDepth > 0 && depth > maxDepth {
+				return newAPIError(http.StatusBadRequest, codeJSONTooComplex, "JSON nests deeper than %d levels", maxDepth)
+			}
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+}
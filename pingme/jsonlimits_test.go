@@ -0,0 +1,67 @@
+package pingme
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// nestedJSON returns a message field holding depth nested arrays
+func nestedJSON(depth int) string {
+	return `{"message": ` + strings.Repeat("[", depth) + strings.Repeat("]", depth) + `}`
+}
+
+// postEchoBody sends body to POST /echo on a server built from cfg
+func postEchoBody(cfg Config, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	NewServer(cfg).ServeHTTP(w, req)
+	return w
+}
+
+// TestJSONShapeLimits tests that deeply nested or token-heavy bodies are rejected before decoding
+func TestJSONShapeLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"deep nesting", nestedJSON(10000)},
+		{"too many tokens", `{"message": "hi", "mode": ` + "[" + strings.Repeat(`"x",`, 1000) + `"x"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertError(t, postEchoBody(DefaultConfig(), tt.body), http.StatusBadRequest, codeJSONTooComplex)
+		})
+	}
+}
+
+// TestJSONShapeLimitsBoundary tests that a body at the depth limit reaches the decoder
+func TestJSONShapeLimitsBoundary(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxJSONDepth = 3
+
+	// The outer object is one level, so two arrays make exactly three
+	assertError(t, postEchoBody(cfg, nestedJSON(2)), http.StatusBadRequest, codeInvalidMessageType)
+	assertError(t, postEchoBody(cfg, nestedJSON(3)), http.StatusBadRequest, codeJSONTooComplex)
+
+	cfg.MaxJSONDepth, cfg.MaxJSONTokens = 0, 0
+	assertError(t, postEchoBody(cfg, nestedJSON(100)), http.StatusBadRequest, codeInvalidMessageType)
+}
+
+// TestJSONShapeLimitsNDJSON tests that each NDJSON line is checked on its own
+func TestJSONShapeLimitsNDJSON(t *testing.T) {
+	responses := postNDJSON(t, "/echo/ndjson", nestedJSON(100)+"\n"+`{"message": "fine"}`+"\n")
+
+	if len(responses) != 2 {
+		t.Fatalf("expected 2 response lines, got %d", len(responses))
+	}
+	if responses[0].ErrorCode != codeJSONTooComplex {
+		t.Errorf("expected line 1 to fail with %s, got %+v", codeJSONTooComplex, responses[0])
+	}
+	if !responses[1].Success {
+		t.Errorf("expected line 2 to echo, got %+v", responses[1])
+	}
+}
@@ -0,0 +1,195 @@
+package pingme
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyReservoirSize bounds how many samples are kept per route
+const latencyReservoirSize = 1024
+
+// pushedTimingSamples bounds how many durations per route are kept for each
+// StatsD push; the rest are accounted for by the sample rate
+const pushedTimingSamples = 100
+
+// LatencyStats summarises the recorded request durations for one route
+type LatencyStats struct {
+	Count int64   `json:"count"`
+	P50   float64 `json:"p50_ms"`
+	P90   float64 `json:"p90_ms"`
+	P99   float64 `json:"p99_ms"`
+}
+
+// latencyTracker records request durations per route using bounded reservoir sampling
+type latencyTracker struct {
+	mu     sync.Mutex
+	size   int
+	routes map[string]*reservoir
+
+	// pending samples the durations recorded since the last StatsD push; it
+	// is nil, and nothing is collected, unless pushing is on
+	pending map[string]*reservoir
+}
+
+// reservoir holds a uniform sample of durations seen for a single route
+type reservoir struct {
+	count   int64
+	sum     time.Duration
+	samples []time.Duration
+}
+
+// add counts d and keeps it in a sample of at most size durations,
+// replacing a random sample once the reservoir is full
+func (res *reservoir) add(d time.Duration, size int) {
+	res.count++
+	res.sum += d
+	if len(res.samples) < size {
+		res.samples = append(res.samples, d)
+		return
+	}
+	if i := rand.Int64N(res.count); i < int64(size) {
+		res.samples[i] = d
+	}
+}
+
+// newLatencyTracker creates a tracker keeping at most size samples per route
+func newLatencyTracker(size int) *latencyTracker {
+	return &latencyTracker{
+		size:   size,
+		routes: make(map[string]*reservoir),
+	}
+}
+
+// record adds a duration for route, replacing a random sample once the reservoir is full
+func (t *latencyTracker) record(route string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	res, ok := t.routes[route]
+	if !ok {
+		res = &reservoir{samples: make([]time.Duration, 0, t.size)}
+		t.routes[route] = res
+	}
+	res.add(d, t.size)
+
+	if t.pending != nil {
+		if t.pending[route] == nil {
+			t.pending[route] = &reservoir{}
+		}
+		t.pending[route].add(d, pushedTimingSamples)
+	}
+}
+
+// collectPending starts keeping the durations recorded between pushes
+func (t *latencyTracker) collectPending() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending = make(map[string]*reservoir)
+}
+
+// drainPending returns the durations recorded since the last call, by route
+func (t *latencyTracker) drainPending() map[string]*reservoir {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pending := t.pending
+	if pending != nil {
+		t.pending = make(map[string]*reservoir)
+	}
+	return pending
+}
+
+// snapshot computes percentiles for every tracked route
+func (t *latencyTracker) snapshot() map[string]LatencyStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := make(map[string]LatencyStats, len(t.routes))
+	for route, res := range t.routes {
+		stats[route] = res.stats()
+	}
+	return stats
+}
+
+// stats computes the reservoir's count and percentiles
+func (res *reservoir) stats() LatencyStats {
+	sorted := append([]time.Duration(nil), res.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return LatencyStats{
+		Count: res.count,
+		P50:   percentile(sorted, 50),
+		P90:   percentile(sorted, 90),
+		P99:   percentile(sorted, 99),
+	}
+}
+
+// writeMetrics prints each route's request count as a counter and its
+// durations as a summary in seconds, in the text exposition format. The
+// quantiles come from the sampled durations, the sum and count from all of them.
+func (t *latencyTracker) writeMetrics(w io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	routes := make([]string, 0, len(t.routes))
+	for route := range t.routes {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+
+	fmt.Fprintln(w, "# HELP http_requests_total Requests served, by route pattern.")
+	fmt.Fprintln(w, "# TYPE http_requests_total counter")
+	for _, route := range routes {
+		fmt.Fprintf(w, "http_requests_total{route=\"%s\"} %d\n", labelEscaper.Replace(route), t.routes[route].count)
+	}
+
+	fmt.Fprintln(w, "# HELP http_request_duration_seconds Request durations, by route pattern.")
+	fmt.Fprintln(w, "# TYPE http_request_duration_seconds summary")
+	for _, route := range routes {
+		res := t.routes[route]
+		stats := res.stats()
+		label := labelEscaper.Replace(route)
+		fmt.Fprintf(w, "http_request_duration_seconds{route=\"%s\",quantile=\"0.5\"} %g\n", label, stats.P50/1000)
+		fmt.Fprintf(w, "http_request_duration_seconds{route=\"%s\",quantile=\"0.9\"} %g\n", label, stats.P90/1000)
+		fmt.Fprintf(w, "http_request_duration_seconds{route=\"%s\",quantile=\"0.99\"} %g\n", label, stats.P99/1000)
+		fmt.Fprintf(w, "http_request_duration_seconds_sum{route=\"%s\"} %g\n", label, res.sum.Seconds())
+		fmt.Fprintf(w, "http_request_duration_seconds_count{route=\"%s\"} %d\n", label, res.count)
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted durations in milliseconds
+func percentile(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return float64(sorted[rank-1]) / float64(time.Millisecond)
+}
+
+// trackLatency records how long each request takes, keyed by its route pattern
+func (s *Server) trackLatency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+
+		// Key by the registered pattern so arbitrary paths can't grow the map
+		_, pattern := s.mux.Handler(r)
+		s.latency.record(pattern, time.Since(start))
+	})
+}
+
+// latencyStatsHandler handles GET /stats/latency requests
+func (s *Server) latencyStatsHandler(w http.ResponseWriter, r *http.Request) {
+	s.respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Latency statistics retrieved successfully",
+		Data:    s.latency.snapshot(),
+	})
+}
@@ -0,0 +1,92 @@
+package pingme
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestLatencyTrackerPercentiles tests percentiles computed from known durations
+func TestLatencyTrackerPercentiles(t *testing.T) {
+	tracker := newLatencyTracker(latencyReservoirSize)
+	for i := 1; i <= 100; i++ {
+		tracker.record("/echo", time.Duration(i)*time.Millisecond)
+	}
+
+	stats, ok := tracker.snapshot()["/echo"]
+	if !ok {
+		t.Fatal("expected stats for /echo")
+	}
+
+	if stats.Count != 100 {
+		t.Errorf("expected count 100, got %d", stats.Count)
+	}
+
+	checks := []struct {
+		name     string
+		value    float64
+		min, max float64
+	}{
+		{"p50", stats.P50, 49, 51},
+		{"p90", stats.P90, 89, 91},
+		{"p99", stats.P99, 98, 100},
+	}
+	for _, c := range checks {
+		if c.value < c.min || c.value > c.max {
+			t.Errorf("expected %s between %.0f and %.0f ms, got %.2f", c.name, c.min, c.max, c.value)
+		}
+	}
+}
+
+// TestLatencyTrackerBounded tests that the reservoir never grows past its size
+func TestLatencyTrackerBounded(t *testing.T) {
+	tracker := newLatencyTracker(10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 250; j++ {
+				tracker.record("/", time.Millisecond)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := len(tracker.routes["/"].samples); got != 10 {
+		t.Errorf("expected 10 retained samples, got %d", got)
+	}
+
+	if got := tracker.snapshot()["/"].Count; got != 1000 {
+		t.Errorf("expected count 1000, got %d", got)
+	}
+}
+
+// TestLatencyStatsHandler tests that served requests show up at /stats/latency
+func TestLatencyStatsHandler(t *testing.T) {
+	server := newTestServer()
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stats/latency", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Success bool                    `json:"success"`
+		Data    map[string]LatencyStats `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if stats, ok := response.Data["GET /healthz"]; !ok || stats.Count != 1 {
+		t.Errorf("expected one recorded /healthz request, got %+v", response.Data)
+	}
+}
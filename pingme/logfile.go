@@ -0,0 +1,54 @@
+package pingme
+
+import (
+	"os"
+	"sync"
+)
+
+// LogFile is a log destination opened in append mode that can be reopened
+// after an external tool such as logrotate has moved it aside
+type LogFile struct {
+	path string
+	mu   sync.Mutex
+	file *os.File
+}
+
+// OpenLogFile opens path for appending, creating it if it doesn't exist
+func OpenLogFile(path string) (*LogFile, error) {
+	l := &LogFile{path: path}
+	if err := l.Reopen(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Write appends p to the current file
+func (l *LogFile) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Write(p)
+}
+
+// Reopen opens the path again and switches writes to it, closing the old
+// file. If the open fails, writes keep going to the old file.
+func (l *LogFile) Reopen() error {
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	old := l.file
+	l.file = file
+	l.mu.Unlock()
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
+
+// Close closes the current file
+func (l *LogFile) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
@@ -0,0 +1,58 @@
+package pingme
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLogFile tests that log lines land in the file and follow it across a rotation
+func TestLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pingme.log")
+	file, err := OpenLogFile(path)
+	if err != nil {
+		t.Fatalf("failed to open log file: %v", err)
+	}
+	defer file.Close()
+	logger := NewLogger(file, DefaultConfig())
+
+	logger.Info("before rotation")
+	rotated := path + ".1"
+	if err := os.Rename(path, rotated); err != nil {
+		t.Fatalf("failed to rotate: %v", err)
+	}
+	if err := file.Reopen(); err != nil {
+		t.Fatalf("failed to reopen: %v", err)
+	}
+	logger.Info("after rotation")
+
+	for name, expected := range map[string]string{rotated: "before rotation", path: "after rotation"} {
+		content, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if lines := strings.Split(strings.TrimSpace(string(content)), "\n"); len(lines) != 1 || !strings.Contains(lines[0], "msg=\""+expected+"\"") {
+			t.Errorf("expected %s to hold only %q, got %q", name, expected, content)
+		}
+	}
+}
+
+// TestLogFileAppends tests that reopening an existing file keeps its earlier lines
+func TestLogFileAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pingme.log")
+	if err := os.WriteFile(path, []byte("existing\n"), 0o644); err != nil {
+		t.Fatalf("failed to seed log file: %v", err)
+	}
+	file, err := OpenLogFile(path)
+	if err != nil {
+		t.Fatalf("failed to open log file: %v", err)
+	}
+	NewLogger(file, DefaultConfig()).Info("appended")
+	file.Close()
+
+	content, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(content), "existing\n") || !strings.Contains(string(content), "appended") {
+		t.Errorf("expected the new line after the existing one, got %q", content)
+	}
+}
@@ -0,0 +1,107 @@
+package pingme
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Supported values for Config.LogFormat
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// parseLogLevel maps debug/info/warn/error to a slog level, defaulting to info
+func parseLogLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// NewLogger builds a leveled logger writing to w using cfg.LogLevel and
+// cfg.LogFormat. A Server logging through it applies its own LogLevel
+// instead, so Reload changes the level of that server only.
+func NewLogger(w io.Writer, cfg Config) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: parseLogLevel(cfg.LogLevel)}
+	if strings.EqualFold(cfg.LogFormat, LogFormatJSON) {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// levelHandler filters records by its own level before passing them to the
+// wrapped handler, whose level it replaces
+type levelHandler struct {
+	level slog.Leveler
+	slog.Handler
+}
+
+// Enabled reports whether l is at or above the handler's level
+func (h *levelHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.level.Level()
+}
+
+// WithAttrs keeps the level on the derived handler
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{level: h.level, Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup keeps the level on the derived handler
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{level: h.level, Handler: h.Handler.WithGroup(name)}
+}
+
+// sampled reports whether a successful request is logged at the given rate.
+// The top-level math/rand/v2 source is safe for concurrent use.
+func sampled(rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	return rate > 0 && rand.Float64() < rate
+}
+
+// logRequests writes one access log line per request. Successful requests are
+// sampled at Config.LogSampleRate; errors and slow requests are always logged.
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := newResponseRecorder(w, nil)
+		next.ServeHTTP(rec, r)
+
+		cfg := s.config()
+		elapsed := time.Since(start)
+		status := rec.Status()
+		slow := cfg.SlowRequestThreshold > 0 && elapsed > cfg.SlowRequestThreshold
+		if status < http.StatusBadRequest && !slow && !sampled(cfg.LogSampleRate) {
+			return
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		s.logger.Log(context.Background(), level, "Request completed",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", status,
+			"bytes", rec.bytes,
+			"request_id", requestIDFromContext(r.Context()),
+			"duration", elapsed,
+		)
+	})
+}
@@ -0,0 +1,114 @@
+package pingme
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestNewLoggerJSON tests that JSON format emits parseable lines at the configured level
+func TestNewLoggerJSON(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.LogFormat = LogFormatJSON
+	cfg.LogLevel = "warn"
+	logger := NewLogger(&buf, cfg)
+
+	logger.Info("filtered out")
+	logger.Warn("disk nearly full", "percent", 91)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected exactly one line at warn level, got %d: %q", len(lines), buf.String())
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("expected JSON log line, got %q: %v", lines[0], err)
+	}
+	if entry["level"] != "WARN" {
+		t.Errorf("expected level WARN, got %v", entry["level"])
+	}
+	if entry["msg"] != "disk nearly full" {
+		t.Errorf("expected msg 'disk nearly full', got %v", entry["msg"])
+	}
+}
+
+// TestNewLoggerDefaults tests that the default config logs info in text format
+func TestNewLoggerDefaults(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, DefaultConfig())
+
+	logger.Debug("hidden")
+	logger.Info("shown")
+
+	output := buf.String()
+	if strings.Contains(output, "hidden") {
+		t.Error("expected debug messages to be filtered at info level")
+	}
+	if !strings.Contains(output, "level=INFO") || !strings.Contains(output, "msg=shown") {
+		t.Errorf("expected text info line, got %q", output)
+	}
+}
+
+// newSampledServer builds a server logging JSON lines to buf at the given sample rate
+func newSampledServer(buf *bytes.Buffer, rate float64) *Server {
+	cfg := DefaultConfig()
+	cfg.LogSampleRate = rate
+	server := NewServer(cfg)
+	server.logger = slog.New(slog.NewJSONHandler(buf, nil))
+	return server
+}
+
+// accessLogStatuses returns the status of every access log line in buf
+func accessLogStatuses(t *testing.T, buf *bytes.Buffer) []int {
+	t.Helper()
+	var statuses []int
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry struct {
+			Msg    string `json:"msg"`
+			Status int    `json:"status"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("expected JSON log line, got %q: %v", line, err)
+		}
+		if entry.Msg == "Request completed" {
+			statuses = append(statuses, entry.Status)
+		}
+	}
+	return statuses
+}
+
+// TestLogSampleRateZero tests that only errors are logged at rate 0
+func TestLogSampleRateZero(t *testing.T) {
+	var buf bytes.Buffer
+	server := newSampledServer(&buf, 0)
+
+	for i := 0; i < 5; i++ {
+		serve(server, http.MethodGet, "/", "")
+	}
+	serve(server, http.MethodGet, "/missing", "")
+
+	statuses := accessLogStatuses(t, &buf)
+	if len(statuses) != 1 || statuses[0] != http.StatusNotFound {
+		t.Errorf("expected only the 404 to be logged, got %v", statuses)
+	}
+}
+
+// TestLogSampleRateOne tests that every request is logged at rate 1
+func TestLogSampleRateOne(t *testing.T) {
+	var buf bytes.Buffer
+	server := newSampledServer(&buf, 1)
+
+	for i := 0; i < 5; i++ {
+		serve(server, http.MethodGet, "/", "")
+	}
+	serve(server, http.MethodGet, "/missing", "")
+
+	if statuses := accessLogStatuses(t, &buf); len(statuses) != 6 {
+		t.Errorf("expected 6 access log lines, got %d: %v", len(statuses), statuses)
+	}
+}
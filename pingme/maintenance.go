@@ -0,0 +1,87 @@
+package pingme
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maintenanceRetryAfter is the Retry-After sent while in maintenance mode
+const maintenanceRetryAfter = 5 * time.Minute
+
+// MaintenanceRequest is the body accepted by POST /admin/maintenance
+type MaintenanceRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// MaintenanceData represents the data returned by the maintenance endpoint
+type MaintenanceData struct {
+	Enabled bool `json:"enabled"`
+}
+
+// maintenanceExempt reports whether a path keeps working in maintenance
+// mode: health checks, so orchestrators don't restart the instance, and the
+// admin endpoints, so maintenance can be switched off again
+func maintenanceExempt(path string) bool {
+	return path == "/healthz" || path == "/readyz" || strings.HasPrefix(path, "/admin/")
+}
+
+// maintenance answers 503 with Retry-After while Config.MaintenanceMode is on
+func (s *Server) maintenance(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.config().MaintenanceMode && !maintenanceExempt(r.URL.Path) {
+			w.Header().Set("Retry-After", retryAfterSeconds(maintenanceRetryAfter))
+			s.respondError(w, newAPIError(http.StatusServiceUnavailable, codeMaintenance, "The API is down for maintenance. Try again later."))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setMaintenance switches maintenance mode without losing a concurrent Reload
+func (s *Server) setMaintenance(enabled bool) {
+	s.updateConfig(func(next *Config) {
+		next.MaintenanceMode = enabled
+	})
+}
+
+// maintenanceHandler handles POST /admin/maintenance by switching
+// maintenance mode on or off
+func (s *Server) maintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdminKey(w, r) {
+		return
+	}
+
+	var req MaintenanceRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&req)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		s.respondError(w, bodyTooLarge(tooLarge))
+		return
+	}
+	if err != nil {
+		s.respondError(w, newAPIError(http.StatusBadRequest, codeInvalidJSON, "Invalid JSON: %v", err))
+		return
+	}
+	if req.Enabled == nil {
+		s.respondError(w, newAPIError(http.StatusBadRequest, codeInvalidBody, `Field "enabled" is required`))
+		return
+	}
+
+	s.setMaintenance(*req.Enabled)
+	s.logger.Warn("Maintenance mode changed", "enabled", *req.Enabled)
+
+	message := "Maintenance mode disabled"
+	if *req.Enabled {
+		message = "Maintenance mode enabled"
+	}
+	s.respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: message,
+		Data:    MaintenanceData{Enabled: *req.Enabled},
+	})
+}
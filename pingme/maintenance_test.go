@@ -0,0 +1,121 @@
+package pingme
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newMaintenanceServer builds a server with the admin key "secret" and logging discarded
+func newMaintenanceServer(enabled bool) *Server {
+	cfg := DefaultConfig()
+	cfg.AdminAPIKey = "secret"
+	cfg.MaintenanceMode = enabled
+	server := NewServer(cfg)
+	server.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	return server
+}
+
+// serve sends a request to the server and returns the recorded response
+func serve(server *Server, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", "secret")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+// TestMaintenanceMode tests that /echo returns 503 while /healthz stays up
+func TestMaintenanceMode(t *testing.T) {
+	server := newMaintenanceServer(true)
+
+	w := serve(server, http.MethodPost, "/echo", `{"message": "hi"}`)
+	if got := w.Header().Get("Retry-After"); got != "300" {
+		t.Errorf("expected Retry-After 300, got %q", got)
+	}
+	assertError(t, w, http.StatusServiceUnavailable, codeMaintenance)
+
+	if w := serve(server, http.MethodGet, "/healthz", ""); w.Code != http.StatusOK {
+		t.Errorf("expected /healthz status 200, got %d", w.Code)
+	}
+}
+
+// TestMaintenanceToggle tests switching maintenance mode through the admin endpoint
+func TestMaintenanceToggle(t *testing.T) {
+	server := newMaintenanceServer(false)
+
+	if w := serve(server, http.MethodPost, "/echo", `{"message": "hi"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200 before maintenance, got %d", w.Code)
+	}
+
+	if w := serve(server, http.MethodPost, "/admin/maintenance", `{"enabled": true}`); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200 enabling maintenance, got %d", w.Code)
+	}
+	if w := serve(server, http.MethodPost, "/echo", `{"message": "hi"}`); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503 during maintenance, got %d", w.Code)
+	}
+	if !server.config().MaintenanceMode {
+		t.Error("expected the running config to report maintenance mode")
+	}
+
+	if w := serve(server, http.MethodPost, "/admin/maintenance", `{"enabled": false}`); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200 disabling maintenance, got %d", w.Code)
+	}
+	if w := serve(server, http.MethodPost, "/echo", `{"message": "hi"}`); w.Code != http.StatusOK {
+		t.Errorf("expected status 200 after maintenance, got %d", w.Code)
+	}
+}
+
+// TestMaintenanceSurvivesReload tests that a reload keeps the runtime toggle until MAINTENANCE_MODE changes
+func TestMaintenanceSurvivesReload(t *testing.T) {
+	server := newMaintenanceServer(false)
+	serve(server, http.MethodPost, "/admin/maintenance", `{"enabled": true}`)
+
+	cfg := DefaultConfig()
+	cfg.AdminAPIKey = "secret"
+	if result, _ := server.Reload(cfg); len(result.Applied) != 0 {
+		t.Errorf("expected nothing applied, got %v", result.Applied)
+	}
+	if !server.config().MaintenanceMode {
+		t.Fatal("expected the reload to keep maintenance mode on")
+	}
+
+	cfg.MaintenanceMode = true
+	server.Reload(cfg)
+	cfg.MaintenanceMode = false
+	if result, _ := server.Reload(cfg); len(result.Applied) != 1 || result.Applied[0] != "MAINTENANCE_MODE" {
+		t.Errorf("expected applied [MAINTENANCE_MODE], got %v", result.Applied)
+	}
+	if server.config().MaintenanceMode {
+		t.Error("expected a changed MAINTENANCE_MODE to switch maintenance off")
+	}
+}
+
+// TestMaintenanceHandlerErrors tests bad toggle requests
+func TestMaintenanceHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{"missing field", `{}`, http.StatusBadRequest, codeInvalidBody},
+		{"not a boolean", `{"enabled": "yes"}`, http.StatusBadRequest, codeInvalidJSON},
+		{"unknown field", `{"enabled": true, "until": "noon"}`, http.StatusBadRequest, codeInvalidJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newMaintenanceServer(false)
+
+			assertError(t, serve(server, http.MethodPost, "/admin/maintenance", tt.body), tt.status, tt.code)
+			if server.config().MaintenanceMode {
+				t.Error("expected maintenance mode to stay off")
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package pingme
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// metrics holds the server's Prometheus-style instruments. They are written
+// in the text exposition format directly, so no client library is needed.
+type metrics struct {
+	inFlight     atomic.Int64
+	requestSize  sizeHistogram
+	responseSize sizeHistogram
+}
+
+// sizeBuckets are the histogram upper bounds in bytes. The 0 bucket keeps
+// empty bodies apart from small ones.
+var sizeBuckets = []int64{0, 100, 1_000, 10_000, 100_000, 1_000_000, 10_000_000}
+
+// sizeHistogram counts byte sizes into sizeBuckets per route path. The zero
+// value is ready to use.
+type sizeHistogram struct {
+	mu    sync.Mutex
+	paths map[string]*sizeCounts
+}
+
+// sizeCounts is one path's histogram; buckets are not cumulative
+type sizeCounts struct {
+	buckets []uint64
+	count   uint64
+	sum     int64
+}
+
+// observe records one size for path
+func (h *sizeHistogram) observe(path string, size int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.paths == nil {
+		h.paths = make(map[string]*sizeCounts)
+	}
+	counts, ok := h.paths[path]
+	if !ok {
+		counts = &sizeCounts{buckets: make([]uint64, len(sizeBuckets))}
+		h.paths[path] = counts
+	}
+	// Sizes above the largest bound only count towards +Inf
+	if i, _ := slices.BinarySearch(sizeBuckets, size); i < len(sizeBuckets) {
+		counts.buckets[i]++
+	}
+	counts.count++
+	counts.sum += size
+}
+
+// write prints the histogram in the text exposition format
+func (h *sizeHistogram) write(w io.Writer, name, help string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+	paths := make([]string, 0, len(h.paths))
+	for path := range h.paths {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	for _, path := range paths {
+		counts := h.paths[path]
+		label := labelEscaper.Replace(path)
+		var cumulative uint64
+		for i, bound := range sizeBuckets {
+			cumulative += counts.buckets[i]
+			fmt.Fprintf(w, "%s_bucket{path=\"%s\",le=\"%d\"} %d\n", name, label, bound, cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket{path=\"%s\",le=\"+Inf\"} %d\n", name, label, counts.count)
+		fmt.Fprintf(w, "%s_sum{path=\"%s\"} %d\n", name, label, counts.sum)
+		fmt.Fprintf(w, "%s_count{path=\"%s\"} %d\n", name, label, counts.count)
+	}
+}
+
+// labelEscaper escapes a label value for the text exposition format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+// Read reads from the wrapped body, adding to the count
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// trackSizes observes the request body bytes the handler read and the
+// response body bytes written, both labelled by route path. Sizes are as
+// sent on the wire, so gzip-encoded bodies count compressed.
+func (s *Server) trackSizes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := &countingReader{ReadCloser: r.Body}
+		r.Body = body
+		rec := newResponseRecorder(w, nil)
+		next.ServeHTTP(rec, r)
+
+		path := routePath(s.mux, r)
+		s.metrics.requestSize.observe(path, body.n)
+		s.metrics.responseSize.observe(path, int64(rec.bytes))
+	})
+}
+
+// routePath returns the path of the mux pattern serving r, without its
+// method, so arbitrary request paths can't grow the label set
+func routePath(mux *http.ServeMux, r *http.Request) string {
+	_, pattern := mux.Handler(r)
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return path
+	}
+	return pattern
+}
+
+// trackInFlight counts requests currently being served. The decrement is
+// deferred so it still runs when a handler panics.
+func (s *Server) trackInFlight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.metrics.inFlight.Add(1)
+		defer s.metrics.inFlight.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// metricsHandler handles GET /metrics in the Prometheus text format
+func (s *Server) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	s.writeMetrics(w)
+}
+
+// writeMetrics prints every instrument in the text exposition format
+func (s *Server) writeMetrics(w io.Writer) {
+	fmt.Fprintln(w, "# HELP http_requests_in_flight Requests currently being served.")
+	fmt.Fprintln(w, "# TYPE http_requests_in_flight gauge")
+	fmt.Fprintf(w, "http_requests_in_flight %d\n", s.metrics.inFlight.Load())
+	s.metrics.requestSize.write(w, "http_request_size_bytes", "Request body bytes read, by route path.")
+	s.metrics.responseSize.write(w, "http_response_size_bytes", "Response body bytes written, by route path.")
+	s.latency.writeMetrics(w)
+}
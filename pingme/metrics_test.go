@@ -0,0 +1,144 @@
+package pingme
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// scrapeGauge fetches /metrics and returns the value of the named sample
+func scrapeGauge(t *testing.T, server *Server, name string) float64 {
+	t.Helper()
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		sample, value, ok := strings.Cut(scanner.Text(), " ")
+		if ok && sample == name {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatalf("invalid value for %s: %q", name, value)
+			}
+			return v
+		}
+	}
+	t.Fatalf("metric %s not found in /metrics", name)
+	return 0
+}
+
+// TestInFlightGauge tests that a request held open shows up in http_requests_in_flight
+func TestInFlightGauge(t *testing.T) {
+	server := newTestServer()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	held := server.trackInFlight(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		held.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		close(done)
+	}()
+	<-started
+
+	// The scrape counts itself, so the held request adds one on top
+	if got := scrapeGauge(t, server, "http_requests_in_flight"); got < 2 {
+		t.Errorf("expected at least 2 requests in flight, got %v", got)
+	}
+
+	close(release)
+	<-done
+	if got := scrapeGauge(t, server, "http_requests_in_flight"); got != 1 {
+		t.Errorf("expected only the scrape in flight, got %v", got)
+	}
+}
+
+// TestInFlightGaugePanic tests that the gauge is decremented when a handler panics
+func TestInFlightGaugePanic(t *testing.T) {
+	server := newTestServer()
+	server.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := server.trackInFlight(server.recoverPanics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := server.metrics.inFlight.Load(); got != 0 {
+		t.Errorf("expected 0 requests in flight after panic, got %d", got)
+	}
+}
+
+// TestSizeHistograms tests that a known-size echo is observed in both size histograms
+func TestSizeHistograms(t *testing.T) {
+	server := newTestServer()
+	body := `{"message": "hello"}`
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	responseBytes := float64(w.Body.Len())
+
+	samples := map[string]float64{
+		`http_request_size_bytes_count{path="/echo"}`:             1,
+		`http_request_size_bytes_sum{path="/echo"}`:               float64(len(body)),
+		`http_request_size_bytes_bucket{path="/echo",le="0"}`:     0,
+		`http_request_size_bytes_bucket{path="/echo",le="100"}`:   1,
+		`http_response_size_bytes_count{path="/echo"}`:            1,
+		`http_response_size_bytes_sum{path="/echo"}`:              responseBytes,
+		`http_response_size_bytes_bucket{path="/echo",le="+Inf"}`: 1,
+	}
+	for name, expected := range samples {
+		if got := scrapeGauge(t, server, name); got != expected {
+			t.Errorf("expected %s %v, got %v", name, expected, got)
+		}
+	}
+}
+
+// TestSizeHistogramsEmptyBody tests that a request without a body is observed as zero bytes
+func TestSizeHistogramsEmptyBody(t *testing.T) {
+	server := newTestServer()
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	samples := map[string]float64{
+		`http_request_size_bytes_count{path="/version"}`:         1,
+		`http_request_size_bytes_sum{path="/version"}`:           0,
+		`http_request_size_bytes_bucket{path="/version",le="0"}`: 1,
+	}
+	for name, expected := range samples {
+		if got := scrapeGauge(t, server, name); got != expected {
+			t.Errorf("expected %s %v, got %v", name, expected, got)
+		}
+	}
+}
+
+// TestSizeHistogramBuckets tests bucket placement at and beyond the bounds
+func TestSizeHistogramBuckets(t *testing.T) {
+	var h sizeHistogram
+	for _, size := range []int64{0, 100, 101, 20_000_000} {
+		h.observe("/echo", size)
+	}
+
+	var out strings.Builder
+	h.write(&out, "test_size_bytes", "Test sizes.")
+	for _, line := range []string{
+		`test_size_bytes_bucket{path="/echo",le="0"} 1`,
+		`test_size_bytes_bucket{path="/echo",le="100"} 2`,
+		`test_size_bytes_bucket{path="/echo",le="1000"} 3`,
+		`test_size_bytes_bucket{path="/echo",le="10000000"} 3`,
+		`test_size_bytes_bucket{path="/echo",le="+Inf"} 4`,
+		`test_size_bytes_sum{path="/echo"} 20000201`,
+		`test_size_bytes_count{path="/echo"} 4`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("expected line %q in:\n%s", line, out.String())
+		}
+	}
+}
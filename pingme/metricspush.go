@@ -0,0 +1,195 @@
+package pingme
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// startMetricsPusher pushes the /metrics instruments to cfg.MetricsPushURL
+// every MetricsPushInterval until Shutdown, which stops the loop and makes
+// one last push. It does nothing when the URL is unset.
+func (s *Server) startMetricsPusher(cfg *Config) {
+	if cfg.MetricsPushURL == "" {
+		return
+	}
+	// Validate rejects URLs that don't parse
+	target, _ := url.Parse(cfg.MetricsPushURL)
+	if target.Scheme == "udp" {
+		s.latency.collectPending()
+	}
+	interval := cfg.MetricsPushInterval
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				if err := s.pushMetrics(ctx, target); err != nil {
+					s.logger.Warn("Metrics push failed", "error", err)
+				}
+				cancel()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	s.OnShutdown(func(ctx context.Context) error {
+		close(stop)
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return s.pushMetrics(ctx, target)
+	})
+}
+
+// pushMetrics sends one snapshot: StatsD metrics in as few datagrams as fit
+// for udp:// targets, otherwise the Prometheus text format PUT to a
+// Pushgateway URL such as http://gateway:9091/metrics/job/pingme
+func (s *Server) pushMetrics(ctx context.Context, target *url.URL) error {
+	var buf bytes.Buffer
+	if target.Scheme == "udp" {
+		s.writeStatsD(&buf)
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "udp", target.Host)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		for _, packet := range statsdPackets(buf.Bytes()) {
+			if _, err := conn.Write(packet); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	s.writeMetrics(&buf)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("push gateway returned %s", res.Status)
+	}
+	return nil
+}
+
+// statsdMaxPacket keeps each datagram within a typical Ethernet MTU
+const statsdMaxPacket = 1432
+
+// statsdPackets splits newline-terminated StatsD lines into datagrams of at
+// most statsdMaxPacket bytes, never breaking a line
+func statsdPackets(lines []byte) [][]byte {
+	var packets [][]byte
+	for len(lines) > 0 {
+		end := len(lines)
+		if end > statsdMaxPacket {
+			// A single line longer than a packet goes out on its own
+			if end = bytes.LastIndexByte(lines[:statsdMaxPacket], '\n') + 1; end == 0 {
+				end = bytes.IndexByte(lines, '\n') + 1
+			}
+		}
+		packets = append(packets, lines[:end])
+		lines = lines[end:]
+	}
+	return packets
+}
+
+// writeStatsD prints the instruments as StatsD metrics. Histogram counts and
+// sums are cumulative, so they are sent as gauges; requests and latencies
+// since the last push are sent as counters and timings by route.
+func (s *Server) writeStatsD(w io.Writer) {
+	fmt.Fprintf(w, "pingme.http_requests_in_flight:%d|g\n", s.metrics.inFlight.Load())
+	s.metrics.requestSize.writeStatsD(w, "pingme.http_request_size_bytes")
+	s.metrics.responseSize.writeStatsD(w, "pingme.http_response_size_bytes")
+	writeStatsDTimings(w, s.latency.drainPending())
+}
+
+// writeStatsDTimings prints each route's request count as a counter and its
+// sampled durations as millisecond timings. When more requests arrived than
+// were sampled, the timings carry the sample rate so StatsD scales them.
+func writeStatsDTimings(w io.Writer, pending map[string]*reservoir) {
+	routes := make([]string, 0, len(pending))
+	for route := range pending {
+		routes = append(routes, route)
+	}
+	slices.Sort(routes)
+	for _, route := range routes {
+		res := pending[route]
+		name := statsdRoute(route)
+		fmt.Fprintf(w, "pingme.http_requests.%s:%d|c\n", name, res.count)
+		rate := ""
+		if int64(len(res.samples)) < res.count {
+			rate = fmt.Sprintf("|@%g", float64(len(res.samples))/float64(res.count))
+		}
+		for _, d := range res.samples {
+			fmt.Fprintf(w, "pingme.http_request_duration.%s:%g|ms%s\n", name, float64(d)/float64(time.Millisecond), rate)
+		}
+	}
+}
+
+// statsdRoute turns a mux pattern such as "POST /echo" into "echo.post".
+// Requests no route matched are grouped as "unmatched".
+func statsdRoute(pattern string) string {
+	if pattern == "" {
+		return "unmatched"
+	}
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		return statsdPath(pattern)
+	}
+	return statsdPath(path) + "." + strings.ToLower(method)
+}
+
+// writeStatsD prints each path's count and sum as StatsD gauges
+func (h *sizeHistogram) writeStatsD(w io.Writer, name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	paths := make([]string, 0, len(h.paths))
+	for path := range h.paths {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	for _, path := range paths {
+		counts := h.paths[path]
+		metric := name + "." + statsdPath(path)
+		fmt.Fprintf(w, "%s.count:%d|g\n", metric, counts.count)
+		fmt.Fprintf(w, "%s.sum:%d|g\n", metric, counts.sum)
+	}
+}
+
+// statsdPath turns a route path into a StatsD name segment: "/echo/file"
+// becomes "echo_file", "/greet/{name}" becomes "greet_name" and "/" becomes "root"
+func statsdPath(path string) string {
+	if name := statsdEscaper.Replace(strings.Trim(path, "/")); name != "" {
+		return name
+	}
+	return "root"
+}
+
+// statsdEscaper replaces the characters StatsD treats as separators
+var statsdEscaper = strings.NewReplacer("/", "_", ".", "_", ":", "_", "|", "_", "{", "", "}", "", "$", "")
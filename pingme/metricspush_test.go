@@ -0,0 +1,183 @@
+package pingme
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newPushServer builds a server pushing metrics to target every few milliseconds
+func newPushServer(target string) *Server {
+	cfg := DefaultConfig()
+	cfg.MetricsPushURL = target
+	cfg.MetricsPushInterval = 5 * time.Millisecond
+	return NewServer(cfg)
+}
+
+// TestMetricsPushGateway tests that metrics, including request counters and
+// latency summaries by route, are PUT to a Pushgateway URL until shutdown
+func TestMetricsPushGateway(t *testing.T) {
+	var pushes atomic.Int64
+	bodies := make(chan string, 100)
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/metrics/job/pingme" {
+			t.Errorf("expected PUT /metrics/job/pingme, got %s %s", r.Method, r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		select {
+		case bodies <- string(body):
+		default:
+		}
+		pushes.Add(1)
+	}))
+	defer gateway.Close()
+
+	server := newPushServer(gateway.URL + "/metrics/job/pingme")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	// A push may go out before the request is recorded, so wait for one after it
+	expected := []string{
+		"# TYPE http_requests_in_flight gauge",
+		`http_requests_total{route="GET /healthz"} 1`,
+		"# TYPE http_request_duration_seconds summary",
+		`http_request_duration_seconds{route="GET /healthz",quantile="0.99"} `,
+		`http_request_duration_seconds_count{route="GET /healthz"} 1`,
+	}
+	deadline := time.After(time.Second)
+	for pushed := false; !pushed; {
+		select {
+		case body := <-bodies:
+			pushed = strings.Contains(body, "http_requests_total{")
+			for _, line := range expected {
+				if pushed && !strings.Contains(body, line) {
+					t.Errorf("expected %q in %q", line, body)
+				}
+			}
+		case <-deadline:
+			t.Fatal("expected a push with the request within a second")
+		}
+	}
+
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	stopped := pushes.Load()
+	time.Sleep(20 * time.Millisecond)
+	if got := pushes.Load(); got != stopped {
+		t.Errorf("expected no pushes after shutdown, got %d more", got-stopped)
+	}
+}
+
+// TestMetricsPushStatsD tests that udp:// targets receive StatsD gauges,
+// request counters and latency timings
+func TestMetricsPushStatsD(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	server := newPushServer("udp://" + conn.LocalAddr().String())
+	defer server.Shutdown(context.Background())
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	}
+
+	// The requests may straddle a push, so read until every metric has shown up
+	var received strings.Builder
+	requests := 0
+	buf := make([]byte, 65536)
+	deadline := time.Now().Add(time.Second)
+	for requests < 2 || !strings.Contains(received.String(), "pingme.http_request_duration.healthz.get:") {
+		conn.SetReadDeadline(deadline)
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("expected counters and timings, got %q: %v", received.String(), err)
+		}
+		if n > statsdMaxPacket {
+			t.Errorf("expected datagrams of at most %d bytes, got %d", statsdMaxPacket, n)
+		}
+		packet := string(buf[:n])
+		received.WriteString(packet)
+		for _, line := range strings.Split(packet, "\n") {
+			if count, ok := strings.CutPrefix(line, "pingme.http_requests.healthz.get:"); ok {
+				value, err := strconv.Atoi(strings.TrimSuffix(count, "|c"))
+				if err != nil || !strings.HasSuffix(count, "|c") {
+					t.Fatalf("expected a counter, got %q", line)
+				}
+				requests += value
+			}
+		}
+	}
+
+	packets := received.String()
+	for _, line := range []string{"pingme.http_requests_in_flight:0|g", "pingme.http_response_size_bytes.healthz.count:"} {
+		if !strings.Contains(packets, line) {
+			t.Errorf("expected %q in %q", line, packets)
+		}
+	}
+	for _, line := range strings.Split(packets, "\n") {
+		if strings.HasPrefix(line, "pingme.http_request_duration.") && !strings.HasSuffix(line, "|ms") {
+			t.Errorf("expected a millisecond timing, got %q", line)
+		}
+	}
+}
+
+// TestWriteStatsDTimingsSampleRate tests that timings carry a sample rate
+// when fewer durations were kept than requests counted
+func TestWriteStatsDTimingsSampleRate(t *testing.T) {
+	res := &reservoir{}
+	for i := 0; i < 4; i++ {
+		res.add(2*time.Millisecond, 2)
+	}
+	var buf strings.Builder
+	writeStatsDTimings(&buf, map[string]*reservoir{"POST /echo": res})
+
+	expected := "pingme.http_requests.echo.post:4|c\n" +
+		"pingme.http_request_duration.echo.post:2|ms|@0.5\n" +
+		"pingme.http_request_duration.echo.post:2|ms|@0.5\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+// TestStatsDPackets tests that lines are split into datagrams without breaking a line
+func TestStatsDPackets(t *testing.T) {
+	line := strings.Repeat("x", 99) + "\n"
+	packets := statsdPackets([]byte(strings.Repeat(line, 30)))
+
+	total := 0
+	for _, packet := range packets {
+		if len(packet) > statsdMaxPacket || len(packet)%len(line) != 0 {
+			t.Errorf("expected whole lines within %d bytes, got %d bytes", statsdMaxPacket, len(packet))
+		}
+		total += len(packet)
+	}
+	if len(packets) != 3 || total != 30*len(line) {
+		t.Errorf("expected 3 packets holding all 3000 bytes, got %d holding %d", len(packets), total)
+	}
+}
+
+// TestStatsDPath tests that route paths become StatsD-safe name segments
+func TestStatsDPath(t *testing.T) {
+	tests := map[string]string{
+		"/":             "root",
+		"/{$}":          "root",
+		"/echo/file":    "echo_file",
+		"/greet/{name}": "greet_name",
+	}
+	for path, expected := range tests {
+		if got := statsdPath(path); got != expected {
+			t.Errorf("statsdPath(%q) = %q, expected %q", path, got, expected)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package pingme
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// middleware wraps a handler with additional behaviour
+type middleware func(http.Handler) http.Handler
+
+// chain wraps h with the middlewares so the first one listed runs first
+func chain(h http.Handler, middlewares ...middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// namedMiddleware is a middleware operators can switch off by name
+type namedMiddleware struct {
+	name    string
+	enabled func(cfg *Config) bool
+	wrap    middleware
+}
+
+// always enables a middleware unless it is listed in Config.DisabledMiddleware
+func always(*Config) bool { return true }
+
+// middlewareRegistry lists every middleware in the fixed order it runs, outermost first
+func (s *Server) middlewareRegistry() []namedMiddleware {
+	return []namedMiddleware{
+		{"request_id", always, s.requestID},
+		{"cors", func(cfg *Config) bool { return len(cfg.CORSAllowedOrigins) > 0 }, s.cors},
+		{"problem_json", always, s.problemDetails},
+		{"msgpack", always, s.msgpackResponses},
+		{"in_flight", always, s.trackInFlight},
+		{"recover", always, s.recoverPanics},
+		{"path_prefix", always, s.stripPathPrefix},
+		{"maintenance", always, s.maintenance},
+		{"latency", always, s.trackLatency},
+		{"sizes", always, s.trackSizes},
+		{"access_log", always, s.logRequests},
+		{"slow_log", always, s.logSlowRequests},
+		{"server_timing", always, s.serverTiming},
+		{"url_length", always, s.limitURLLength},
+		{"header_count", always, s.limitHeaderCount},
+		{"body_limit", func(cfg *Config) bool { return cfg.MaxBodyBytes > 0 }, s.limitBodySize},
+		{"rate_limit", always, s.rateLimit},
+		{"concurrency", always, s.limitConcurrency},
+		{"gzip", func(cfg *Config) bool { return cfg.Gzip }, s.gzipResponses},
+	}
+}
+
+// enabledMiddlewares filters the registry down to the middlewares cfg enables, keeping their order
+func enabledMiddlewares(cfg *Config, registry []namedMiddleware) []namedMiddleware {
+	var enabled []namedMiddleware
+	for _, mw := range registry {
+		if mw.enabled(cfg) && !cfg.middlewareDisabled(mw.name) {
+			enabled = append(enabled, mw)
+		}
+	}
+	return enabled
+}
+
+// buildChain wraps h with the registry's middlewares that cfg enables
+func buildChain(h http.Handler, cfg *Config, registry []namedMiddleware) http.Handler {
+	var wraps []middleware
+	for _, mw := range enabledMiddlewares(cfg, registry) {
+		wraps = append(wraps, mw.wrap)
+	}
+	return chain(h, wraps...)
+}
+
+// limitURLLength rejects requests whose URL exceeds Config.MaxURLLength
+func (s *Server) limitURLLength(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		maxLength := s.config().MaxURLLength
+		if maxLength > 0 && len(r.URL.String()) > maxLength {
+			s.respondError(w, newAPIError(http.StatusRequestURITooLong, codeURITooLong, "URL exceeds the maximum length of %d characters", maxLength))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitHeaderCount rejects requests with more header lines than
+// Config.MaxHeaderCount. Every value counts, so repeating one header name
+// can't slip past the limit the way len(r.Header) would allow.
+func (s *Server) limitHeaderCount(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		maxCount := s.config().MaxHeaderCount
+		if maxCount > 0 {
+			count := 0
+			for _, values := range r.Header {
+				count += len(values)
+			}
+			if count > maxCount {
+				s.respondError(w, newAPIError(http.StatusRequestHeaderFieldsTooLarge, codeHeadersTooLarge, "Request has %d headers, more than the maximum of %d", count, maxCount))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// stripPathPrefix serves routes beneath Config.PathPrefix, removing it before dispatch
+func (s *Server) stripPathPrefix(next http.Handler) http.Handler {
+	prefix := strings.TrimSuffix(s.config().PathPrefix, "/")
+	if prefix == "" {
+		return next
+	}
+	stripped := http.StripPrefix(prefix, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The bare prefix maps to the root route
+		if r.URL.Path == prefix {
+			u := *r.URL
+			u.Path = prefix + "/"
+			r2 := *r
+			r2.URL = &u
+			r = &r2
+		}
+
+		if !strings.HasPrefix(r.URL.Path, prefix+"/") {
+			s.notFound(w, r)
+			return
+		}
+		stripped.ServeHTTP(w, r)
+	})
+}
+
+// limitBodySize caps request bodies at Config.MaxBodyBytes; reads past it fail
+// with an *http.MaxBytesError that handlers report via respondBodyError. The
+// cap counts bytes actually read, never the declared Content-Length, which a
+// client can set to anything; no handler sizes a buffer from it either.
+func (s *Server) limitBodySize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, s.config().MaxBodyBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitConcurrency rejects requests with 503 once Config.MaxConcurrent are in flight
+func (s *Server) limitConcurrency(next http.Handler) http.Handler {
+	if s.slots == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case s.slots <- struct{}{}:
+			defer func() { <-s.slots }()
+			next.ServeHTTP(w, r)
+		default:
+			w.Header().Set("Retry-After", "1")
+			s.respondError(w, newAPIError(http.StatusServiceUnavailable, codeOverloaded, "Server is at capacity. Try again later."))
+		}
+	})
+}
+
+// serverTiming reports handler duration in a Server-Timing header set just before the headers are sent
+func (s *Server) serverTiming(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := newResponseRecorder(w, func(h http.Header) {
+			elapsed := float64(time.Since(start)) / float64(time.Millisecond)
+			h.Set("Server-Timing", fmt.Sprintf("app;dur=%.3f", elapsed))
+		})
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// logSlowRequests warns about requests slower than Config.SlowRequestThreshold
+func (s *Server) logSlowRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		threshold := s.config().SlowRequestThreshold
+		if threshold <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		if elapsed := time.Since(start); elapsed > threshold {
+			s.logger.Warn("Slow request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"request_id", requestIDFromContext(r.Context()),
+				"duration", elapsed,
+			)
+		}
+	})
+}
+
+// recoverPanics turns a handler panic into a JSON 500 instead of a dropped connection
+func (s *Server) recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+			s.logger.Error("Handler panicked",
+				"request_id", requestIDFromContext(r.Context()),
+				"error", err,
+				"stack", string(debug.Stack()),
+			)
+			s.respondError(w, newAPIError(http.StatusInternalServerError, codeInternal, "Internal server error"))
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,335 @@
+package pingme
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestChainOrder tests that chain runs middlewares in the order listed
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	record := func(name string) middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	final := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	})
+
+	handler := chain(final, record("first"), record("second"))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := strings.Join(calls, ","); got != "first,second,handler" {
+		t.Errorf("expected call order first,second,handler, got %s", got)
+	}
+}
+
+// TestLimitURLLength tests that over-length URLs are rejected with 414
+func TestLimitURLLength(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxURLLength = 64
+	server := NewServer(cfg)
+
+	req := httptest.NewRequest(http.MethodGet, "/?name="+strings.Repeat("a", 100), nil)
+	w := httptest.NewRecorder()
+
+	server.ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusRequestURITooLong {
+		t.Errorf("expected status 414, got %d", res.StatusCode)
+	}
+
+	var response Response
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if response.Success {
+		t.Error("expected success to be false for over-length URL")
+	}
+
+	// A short URL still passes through
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200 for short URL, got %d", w.Code)
+	}
+}
+
+// TestLimitHeaderCount tests that requests with more header lines than MaxHeaderCount get 431
+func TestLimitHeaderCount(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxHeaderCount = 10
+	server := NewServer(cfg)
+
+	tests := []struct {
+		name string
+		add  func(h http.Header, i int)
+	}{
+		{"distinct names", func(h http.Header, i int) { h.Set("X-Flood-"+strconv.Itoa(i), "x") }},
+		{"repeated name", func(h http.Header, i int) { h.Add("X-Flood", "x") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+			for i := 0; i < 11; i++ {
+				tt.add(req.Header, i)
+			}
+			w := httptest.NewRecorder()
+
+			server.ServeHTTP(w, req)
+
+			assertError(t, w, http.StatusRequestHeaderFieldsTooLarge, codeHeadersTooLarge)
+		})
+	}
+
+	// A request at the limit still passes through
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	for i := 0; i < 10; i++ {
+		req.Header.Add("X-Flood", "x")
+	}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200 at the limit, got %d", w.Code)
+	}
+}
+
+// TestStripPathPrefix tests routing with and without a configured path prefix
+func TestStripPathPrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		path     string
+		expected int
+	}{
+		{"prefixed health", "/pingme", "/pingme/healthz", http.StatusOK},
+		{"prefixed root", "/pingme", "/pingme/", http.StatusOK},
+		{"bare prefix", "/pingme", "/pingme", http.StatusOK},
+		{"trailing slash in prefix", "/pingme/", "/pingme/healthz", http.StatusOK},
+		{"unprefixed path with prefix set", "/pingme", "/healthz", http.StatusNotFound},
+		{"similar prefix", "/pingme", "/pingmeextra/healthz", http.StatusNotFound},
+		{"no prefix", "", "/healthz", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.PathPrefix = tt.prefix
+			w := httptest.NewRecorder()
+
+			NewServer(cfg).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.expected {
+				t.Errorf("expected status %d for %s, got %d", tt.expected, tt.path, w.Code)
+			}
+		})
+	}
+}
+
+// TestStripPathPrefixEcho tests that echo resolves beneath the prefix
+func TestStripPathPrefixEcho(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.PathPrefix = "/pingme"
+
+	req := httptest.NewRequest(http.MethodPost, "/pingme/echo", strings.NewReader(`{"message": "hi"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	NewServer(cfg).ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+}
+
+// TestLimitConcurrency tests that a request beyond MaxConcurrent gets 503 while the slot is held
+func TestLimitConcurrency(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxConcurrent = 1
+	server := NewServer(cfg)
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	blocking := server.limitConcurrency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		blocking.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		close(done)
+	}()
+	<-entered
+
+	w := httptest.NewRecorder()
+	blocking.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header on 503")
+	}
+
+	close(release)
+	<-done
+
+	// The slot is released once the blocking request finishes
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200 after release, got %d", w.Code)
+	}
+}
+
+// TestServerTiming tests that responses report a non-negative app duration
+func TestServerTiming(t *testing.T) {
+	w := httptest.NewRecorder()
+	newTestServer().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	header := w.Header().Get("Server-Timing")
+	value, ok := strings.CutPrefix(header, "app;dur=")
+	if !ok {
+		t.Fatalf("expected Server-Timing app;dur=<ms>, got %q", header)
+	}
+
+	duration, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		t.Fatalf("failed to parse duration %q: %v", value, err)
+	}
+	if duration < 0 {
+		t.Errorf("expected non-negative duration, got %v", duration)
+	}
+}
+
+// TestRecoverPanics tests that a panicking handler produces a JSON 500
+func TestRecoverPanics(t *testing.T) {
+	server := newTestServer()
+	server.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := server.recoverPanics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected Content-Type application/json, got %q", ct)
+	}
+}
+
+// TestLogSlowRequests tests that only requests over SlowRequestThreshold are logged
+func TestLogSlowRequests(t *testing.T) {
+	tests := []struct {
+		name   string
+		delay  time.Duration
+		logged bool
+	}{
+		{"slow", 20 * time.Millisecond, true},
+		{"fast", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			cfg := DefaultConfig()
+			cfg.SlowRequestThreshold = 5 * time.Millisecond
+			server := NewServer(cfg)
+			server.logger = slog.New(slog.NewTextHandler(&logs, nil))
+			handler := server.logSlowRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(tt.delay)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/slow", nil)
+			req = req.WithContext(context.WithValue(req.Context(), requestIDKey{}, "req-1"))
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			got := logs.String()
+			if !tt.logged {
+				if got != "" {
+					t.Errorf("expected no log for a fast request, got %q", got)
+				}
+				return
+			}
+			for _, want := range []string{"level=WARN", "method=GET", "path=/slow", "request_id=req-1", "duration="} {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected log to contain %q, got %q", want, got)
+				}
+			}
+		})
+	}
+}
+
+// TestBuildChainSubset tests that only enabled middlewares run, in registry order
+func TestBuildChainSubset(t *testing.T) {
+	var calls []string
+	record := func(name string) middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	never := func(*Config) bool { return false }
+
+	registry := []namedMiddleware{
+		{"first", always, record("first")},
+		{"off_by_predicate", never, record("off_by_predicate")},
+		{"off_by_config", always, record("off_by_config")},
+		{"last", always, record("last")},
+	}
+	cfg := DefaultConfig()
+	cfg.DisabledMiddleware = []string{"off_by_config"}
+
+	handler := buildChain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), &cfg, registry)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := strings.Join(calls, ","); got != "first,last" {
+		t.Errorf("expected only first,last to run, got %s", got)
+	}
+}
+
+// TestDisableMiddlewareEnv tests that DISABLE_MIDDLEWARE switches off server middlewares
+func TestDisableMiddlewareEnv(t *testing.T) {
+	t.Setenv("DISABLE_MIDDLEWARE", "gzip, server_timing")
+	server := NewServer(LoadConfig())
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("expected gzip to be disabled, got Content-Encoding %q", got)
+	}
+	if got := w.Header().Get("Server-Timing"); got != "" {
+		t.Errorf("expected server_timing to be disabled, got %q", got)
+	}
+	if got := w.Header().Get("X-Request-ID"); got == "" {
+		t.Error("expected request_id to stay enabled")
+	}
+}
@@ -0,0 +1,107 @@
+package pingme
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// msgpackContentType is the media type for MessagePack responses
+const msgpackContentType = "application/msgpack"
+
+// msgpackWriter marks a response that should be encoded as MessagePack
+type msgpackWriter struct {
+	http.ResponseWriter
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *msgpackWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// msgpackTarget reports whether a msgpackWriter sits beneath any wrappers added after it
+func msgpackTarget(w http.ResponseWriter) bool {
+	for {
+		switch t := w.(type) {
+		case *msgpackWriter:
+			return true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = t.Unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+// wantsMsgpack reports whether the client's Accept header lists MessagePack
+func wantsMsgpack(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err == nil && (mediaType == msgpackContentType || mediaType == "application/x-msgpack") {
+			return true
+		}
+	}
+	return false
+}
+
+// msgpackResponses marks responses for clients accepting MessagePack so
+// writeJSON encodes them in that format
+func (s *Server) msgpackResponses(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wantsMsgpack(r) {
+			w = &msgpackWriter{ResponseWriter: w}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeMsgpack encodes body as the MessagePack response with the specified status code
+func (s *Server) writeMsgpack(w http.ResponseWriter, statusCode int, body interface{}) {
+	pooled := s.config().PoolBuffers
+	buf := getBuffer(pooled)
+	defer putBuffer(buf, pooled)
+	if err := encodeMsgpack(buf, body, pooled); err != nil {
+		s.logger.Error("Error encoding MessagePack response", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", msgpackContentType)
+	w.Header().Set("X-API-Version", APIVersion)
+
+	w.WriteHeader(statusCode)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		s.logWriteError("Error writing MessagePack response", err)
+	}
+}
+
+// marshalMsgpack encodes v as MessagePack. Struct fields are named by their
+// json tags and follow the same omitempty rules, whole numbers take the
+// smallest integer type that holds them and floats stay 64-bit floats.
+func marshalMsgpack(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeMsgpack(&buf, v, false); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeMsgpack writes v to w as marshalMsgpack does, with an encoder from
+// the library's pool when pooled is set. Reset clears the options a pooled
+// encoder was last used with before they are set again.
+func encodeMsgpack(w io.Writer, v interface{}, pooled bool) error {
+	var encoder *msgpack.Encoder
+	if pooled {
+		encoder = msgpack.GetEncoder()
+		defer msgpack.PutEncoder(encoder)
+		encoder.Reset(w)
+	} else {
+		encoder = msgpack.NewEncoder(w)
+	}
+	encoder.SetCustomStructTag("json")
+	encoder.UseCompactInts(true)
+	return encoder.Encode(v)
+}
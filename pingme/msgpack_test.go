@@ -0,0 +1,127 @@
+package pingme
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// decodeMsgpack decodes a MessagePack body into generic maps and slices
+func decodeMsgpack(t *testing.T, data []byte) map[string]interface{} {
+	t.Helper()
+	var decoded map[string]interface{}
+	if err := msgpack.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("invalid MessagePack: %v", err)
+	}
+	return decoded
+}
+
+// TestHealthMsgpack tests that Accept: application/msgpack returns the
+// health response encoded as MessagePack with the JSON field names
+func TestHealthMsgpack(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("Accept", "application/msgpack")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/msgpack" {
+		t.Errorf("expected Content-Type application/msgpack, got %q", ct)
+	}
+
+	response := decodeMsgpack(t, w.Body.Bytes())
+	if response["success"] != true {
+		t.Errorf("expected success true, got %v", response["success"])
+	}
+	data, ok := response["data"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected data to be a map, got %v", response["data"])
+	}
+	if data["status"] != "healthy" {
+		t.Errorf("expected status healthy, got %v", data["status"])
+	}
+	if _, ok := data["time"].(time.Time); !ok {
+		t.Errorf("expected time as a MessagePack timestamp, got %T", data["time"])
+	}
+}
+
+// TestMsgpackErrors tests that error responses are MessagePack too
+func TestMsgpackErrors(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"message": ""}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/msgpack")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+	response := decodeMsgpack(t, w.Body.Bytes())
+	if response["error_code"] != codeEmptyMessage {
+		t.Errorf("expected error_code %s, got %v", codeEmptyMessage, response["error_code"])
+	}
+}
+
+// TestMsgpackFloatsStayFloats tests that a float field holding a whole number
+// is still encoded as a float, with and without ?fields= and camelCase keys
+func TestMsgpackFloatsStayFloats(t *testing.T) {
+	tests := []struct {
+		name     string
+		jsonCase string
+		target   string
+	}{
+		{"envelope", JSONCaseSnake, "/echo"},
+		{"fields", JSONCaseSnake, "/echo?fields=entropy,length"},
+		{"camel", JSONCaseCamel, "/echo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.JSONCase = tt.jsonCase
+			req := httptest.NewRequest(http.MethodPost, tt.target, bytes.NewBufferString(`{"message": "aaaa", "stats": true}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "application/msgpack")
+			w := httptest.NewRecorder()
+
+			NewServer(cfg).ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", w.Code)
+			}
+			data, ok := decodeMsgpack(t, w.Body.Bytes())["data"].(map[string]interface{})
+			if !ok {
+				t.Fatal("expected data to be a map")
+			}
+			if entropy, ok := data["entropy"].(float64); !ok || entropy != 0 {
+				t.Errorf("expected entropy as the float 0, got %T %v", data["entropy"], data["entropy"])
+			}
+			if length, ok := data["length"].(int8); !ok || length != 4 {
+				t.Errorf("expected length as the integer 4, got %T %v", data["length"], data["length"])
+			}
+		})
+	}
+}
+
+// TestMarshalMsgpackTags tests that the json tags name the fields and that
+// omitempty fields are left out as they are in JSON
+func TestMarshalMsgpackTags(t *testing.T) {
+	encoded, err := marshalMsgpack(Response{Success: true, Message: "ok"})
+	if err != nil {
+		t.Fatalf("marshalMsgpack: %v", err)
+	}
+	expected := map[string]interface{}{"success": true, "message": "ok"}
+	if got := decodeMsgpack(t, encoded); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
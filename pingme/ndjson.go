@@ -0,0 +1,134 @@
+package pingme
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// maxNDJSONLine caps a single line of an /echo/ndjson body
+const maxNDJSONLine = 64 << 10
+
+// batchItem is one /echo/ndjson line: an echo request with an optional
+// client-chosen id for correlating it with its result
+type batchItem struct {
+	ID string `json:"id,omitempty"`
+	EchoRequest
+}
+
+// batchResult is the echo of one line, tagged with the line's id
+type batchResult struct {
+	ID string `json:"id,omitempty"`
+	EchoData
+}
+
+// ndjsonEchoHandler handles POST /echo/ndjson, reading one EchoRequest per
+// line and streaming one Response per line. A malformed line produces an
+// error line and processing continues, unless ?strict=true is set, in
+// which case the stream ends after the error.
+func (s *Server) ndjsonEchoHandler(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/x-ndjson" {
+		s.respondError(w, newAPIError(http.StatusUnsupportedMediaType, codeUnsupportedMediaType, "Content-Type must be application/x-ndjson"))
+		return
+	}
+	strict, _ := strconv.ParseBool(r.URL.Query().Get("strict"))
+
+	// On HTTP/1.x the server discards whatever body is still unread at the
+	// first flush unless full duplex is on. Where it can't be enabled, read
+	// the whole body before answering instead.
+	var body io.Reader = r.Body
+	if err := http.NewResponseController(w).EnableFullDuplex(); err != nil {
+		buffered, err := io.ReadAll(r.Body)
+		if err != nil {
+			s.respondBodyError(w, err, "Error reading body")
+			return
+		}
+		body = bytes.NewReader(buffered)
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("X-API-Version", APIVersion)
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	flusher := http.NewResponseController(w)
+	send := func(response Response) bool {
+		if err := encoder.Encode(s.envelope(response)); err != nil {
+			s.logWriteError("Error writing NDJSON response", err)
+			return false
+		}
+		_ = flusher.Flush()
+		return true
+	}
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), maxNDJSONLine)
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		data, err := s.echoLine(raw)
+		if err != nil {
+			failed := Response{Success: false, Error: fmt.Sprintf("line %d: %v", line, err), ErrorCode: errorCode(err)}
+			if data.ID != "" {
+				failed.Data = map[string]string{"id": data.ID}
+			}
+			if !send(failed) || strict {
+				return
+			}
+			continue
+		}
+		if !send(Response{Success: true, Data: data}) {
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		failed := newAPIError(http.StatusBadRequest, codeInvalidBody, "Error reading body: %v", err)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			failed = bodyTooLarge(tooLarge)
+		} else if errors.Is(err, bufio.ErrTooLong) {
+			failed = newAPIError(http.StatusRequestEntityTooLarge, codePayloadTooLarge, "Line exceeds the maximum length of %d bytes", maxNDJSONLine)
+		}
+		send(Response{Success: false, Error: failed.Message, ErrorCode: failed.Code})
+	}
+}
+
+// echoLine decodes and echoes a single NDJSON line. On failure the result
+// still carries the line's id if it could be decoded.
+func (s *Server) echoLine(raw []byte) (batchResult, error) {
+	if err := s.checkJSONShape(raw); err != nil {
+		return batchResult{}, err
+	}
+	if err := s.checkRawUTF8(raw); err != nil {
+		return batchResult{}, err
+	}
+	var item batchItem
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&item); err != nil {
+		return batchResult{}, invalidEchoJSON(err)
+	}
+
+	result := batchResult{ID: item.ID}
+	// Lines are echoed back to back, so a per-line delay has nothing to apply to
+	if item.DelayMs != 0 {
+		return result, newAPIError(http.StatusBadRequest, codeInvalidDelay, "delay_ms is not supported in batch lines")
+	}
+	transforms, err := s.validateEcho(item.EchoRequest)
+	if err != nil {
+		return result, err
+	}
+	result.EchoData, err = s.buildEcho(item.EchoRequest, transforms)
+	return result, err
+}
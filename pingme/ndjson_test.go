@@ -0,0 +1,167 @@
+package pingme
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// postNDJSON streams body to /echo/ndjson and returns the decoded response lines
+func postNDJSON(t *testing.T, path, body string) []Response {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("expected Content-Type application/x-ndjson, got %q", ct)
+	}
+
+	var responses []Response
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var response Response
+		if err := json.Unmarshal(scanner.Bytes(), &response); err != nil {
+			t.Fatalf("invalid response line %q: %v", scanner.Text(), err)
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+// TestNDJSONEcho tests that a malformed line yields an error line and the stream continues
+func TestNDJSONEcho(t *testing.T) {
+	body := `{"message": "one"}
+{"message": oops}
+{"message": "three", "mode": "upper"}
+`
+	responses := postNDJSON(t, "/echo/ndjson", body)
+
+	if len(responses) != 3 {
+		t.Fatalf("expected 3 response lines, got %d", len(responses))
+	}
+	if !responses[0].Success || echoedValue(t, responses[0]) != "Echo: one" {
+		t.Errorf("expected first line to echo, got %+v", responses[0])
+	}
+	if responses[1].Success || !strings.HasPrefix(responses[1].Error, "line 2:") {
+		t.Errorf("expected an error for line 2, got %+v", responses[1])
+	}
+	if !responses[2].Success || echoedValue(t, responses[2]) != "THREE" {
+		t.Errorf("expected third line to echo THREE, got %+v", responses[2])
+	}
+}
+
+// TestNDJSONEchoStrict tests that strict mode stops at the first malformed line
+func TestNDJSONEchoStrict(t *testing.T) {
+	body := `{"message": "one"}
+{"message": ""}
+{"message": "three"}
+`
+	responses := postNDJSON(t, "/echo/ndjson?strict=true", body)
+
+	if len(responses) != 2 {
+		t.Fatalf("expected the stream to stop after 2 lines, got %d", len(responses))
+	}
+	if responses[1].Success {
+		t.Error("expected the last line to be the error")
+	}
+}
+
+// TestNDJSONEchoIDs tests that each result, including an error, carries its line's id in input order
+func TestNDJSONEchoIDs(t *testing.T) {
+	body := `{"id": "a", "message": "one"}
+{"id": "b", "message": ""}
+{"message": "three"}
+{"id": "d", "message": 4}
+`
+	responses := postNDJSON(t, "/echo/ndjson", body)
+
+	if len(responses) != 4 {
+		t.Fatalf("expected 4 response lines, got %d", len(responses))
+	}
+	expected := []interface{}{"a", "b", nil, nil}
+	for i, response := range responses {
+		var id interface{}
+		if data, ok := response.Data.(map[string]interface{}); ok {
+			id = data["id"]
+		}
+		if id != expected[i] {
+			t.Errorf("line %d: expected id %v, got %v", i+1, expected[i], id)
+		}
+	}
+	if !responses[0].Success || echoedValue(t, responses[0]) != "Echo: one" {
+		t.Errorf("expected line 1 to echo, got %+v", responses[0])
+	}
+	if responses[1].Success || responses[1].ErrorCode != codeEmptyMessage {
+		t.Errorf("expected line 2 to fail with %s, got %+v", codeEmptyMessage, responses[1])
+	}
+	if responses[3].ErrorCode != codeInvalidMessageType {
+		t.Errorf("expected line 4 to fail with %s, got %+v", codeInvalidMessageType, responses[3])
+	}
+}
+
+// TestNDJSONEchoLargeBodyOverHTTP tests that a body larger than the scanner
+// buffer is read to the end over a real HTTP/1.1 connection, where the
+// server would otherwise drop the unread body at the first flush
+func TestNDJSONEchoLargeBodyOverHTTP(t *testing.T) {
+	ts := httptest.NewServer(newTestServer())
+	defer ts.Close()
+
+	const lines = 500
+	var body strings.Builder
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&body, "{\"id\": \"%d\", \"message\": \"line number %d\"}\n", i, i)
+	}
+	if body.Len() <= 4096 {
+		t.Fatalf("expected a body larger than 4KB, got %d bytes", body.Len())
+	}
+
+	resp, err := http.Post(ts.URL+"/echo/ndjson", "application/x-ndjson", strings.NewReader(body.String()))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	count := 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var response Response
+		if err := json.Unmarshal(scanner.Bytes(), &response); err != nil {
+			t.Fatalf("invalid response line %q: %v", scanner.Text(), err)
+		}
+		if !response.Success {
+			t.Fatalf("line %d: unexpected error %q", count+1, response.Error)
+		}
+		count++
+	}
+	if count != lines {
+		t.Errorf("expected %d results, got %d", lines, count)
+	}
+}
+
+// TestNDJSONEchoRejectsDelay tests that delay_ms on a batch line is an error rather than ignored
+func TestNDJSONEchoRejectsDelay(t *testing.T) {
+	body := `{"id": "a", "message": "one", "delay_ms": 100}
+{"id": "b", "message": "two"}
+`
+	responses := postNDJSON(t, "/echo/ndjson", body)
+
+	if len(responses) != 2 {
+		t.Fatalf("expected 2 response lines, got %d", len(responses))
+	}
+	if responses[0].Success || responses[0].ErrorCode != codeInvalidDelay {
+		t.Errorf("expected line 1 to fail with %s, got %+v", codeInvalidDelay, responses[0])
+	}
+	if !responses[1].Success {
+		t.Errorf("expected line 2 to echo, got %+v", responses[1])
+	}
+}
@@ -0,0 +1,12 @@
+// Package pingme implements the PingMe API routes so they can be served by
+// the pingme-api binary or mounted inside a larger application.
+package pingme
+
+import "net/http"
+
+// Handler returns the fully-wired PingMe router configured from the
+// environment, ready to be served directly or mounted inside another
+// application's mux. Use NewServer for explicit configuration.
+func Handler() http.Handler {
+	return NewServer(LoadConfig())
+}
@@ -0,0 +1,62 @@
+package pingme
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestHandler tests that Handler exposes every route when mounted directly
+func TestHandler(t *testing.T) {
+	ts := httptest.NewServer(Handler())
+	defer ts.Close()
+
+	// Test greeting route
+	res, err := http.Get(ts.URL + "/")
+	if err != nil {
+		t.Fatalf("failed to GET /: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 from /, got %d", res.StatusCode)
+	}
+
+	// Test health route
+	res, err = http.Get(ts.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("failed to GET /healthz: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 from /healthz, got %d", res.StatusCode)
+	}
+
+	// Test echo route
+	payload := bytes.NewBufferString(`{"message": "test"}`)
+	res, err = http.Post(ts.URL+"/echo", "application/json", payload)
+	if err != nil {
+		t.Fatalf("failed to POST /echo: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 from /echo, got %d", res.StatusCode)
+	}
+}
+
+// TestHandlerMounted tests that Handler works under a host application's prefix
+func TestHandlerMounted(t *testing.T) {
+	host := http.NewServeMux()
+	host.Handle("/pingme/", http.StripPrefix("/pingme", Handler()))
+	ts := httptest.NewServer(host)
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/pingme/healthz")
+	if err != nil {
+		t.Fatalf("failed to GET /pingme/healthz: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 from /pingme/healthz, got %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,53 @@
+package pingme
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBufferBytes keeps the occasional large response from pinning its
+// buffer in the pool; bigger buffers are left to the garbage collector
+const maxPooledBufferBytes = 64 << 10
+
+// echoRequestPool and bufferPool back the Config.PoolBuffers fast path;
+// MessagePack encoders come from the msgpack library's own pool
+var (
+	echoRequestPool = sync.Pool{New: func() interface{} { return new(EchoRequest) }}
+	bufferPool      = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+)
+
+// getEchoRequest returns a zeroed EchoRequest, from the pool when pooled is set
+func getEchoRequest(pooled bool) *EchoRequest {
+	if !pooled {
+		return new(EchoRequest)
+	}
+	return echoRequestPool.Get().(*EchoRequest)
+}
+
+// putEchoRequest zeroes req and returns it to the pool when pooled is set,
+// so no field of one request leaks into the next
+func putEchoRequest(req *EchoRequest, pooled bool) {
+	if !pooled {
+		return
+	}
+	*req = EchoRequest{}
+	echoRequestPool.Put(req)
+}
+
+// getBuffer returns an empty buffer, from the pool when pooled is set
+func getBuffer(pooled bool) *bytes.Buffer {
+	if !pooled {
+		return new(bytes.Buffer)
+	}
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer empties buf and returns it to the pool when pooled is set. The
+// caller must be done with buf.Bytes() by then.
+func putBuffer(buf *bytes.Buffer, pooled bool) {
+	if !pooled || buf.Cap() > maxPooledBufferBytes {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
@@ -0,0 +1,149 @@
+package pingme
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestPutEchoRequestResets tests that a pooled EchoRequest comes back zeroed
+func TestPutEchoRequestResets(t *testing.T) {
+	req := getEchoRequest(true)
+	*req = EchoRequest{Message: "secret", Mode: "upper", Stats: true, DelayMs: 50}
+
+	putEchoRequest(req, true)
+
+	if *req != (EchoRequest{}) {
+		t.Errorf("expected a zeroed request, got %+v", *req)
+	}
+	if got := getEchoRequest(true); *got != (EchoRequest{}) {
+		t.Errorf("expected the pool to hand out a zeroed request, got %+v", *got)
+	}
+}
+
+// TestPutBufferResets tests that a pooled buffer comes back empty and that
+// oversized buffers are left out of the pool
+func TestPutBufferResets(t *testing.T) {
+	buf := getBuffer(true)
+	buf.WriteString(`{"success":true}`)
+
+	putBuffer(buf, true)
+
+	if buf.Len() != 0 {
+		t.Errorf("expected an empty buffer, got %q", buf.String())
+	}
+	if got := getBuffer(true); got.Len() != 0 {
+		t.Errorf("expected the pool to hand out an empty buffer, got %q", got.String())
+	}
+
+	large := bytes.NewBuffer(make([]byte, 0, maxPooledBufferBytes+1))
+	large.WriteString("kept")
+	putBuffer(large, true)
+	if large.String() != "kept" {
+		t.Errorf("expected an oversized buffer to be left alone, got %q", large.String())
+	}
+}
+
+// comparableBody decodes a JSON or MessagePack echo response, dropping the
+// wall-clock processing_us and noting whether a JSON body ends in a newline
+func comparableBody(t *testing.T, w *httptest.ResponseRecorder) interface{} {
+	t.Helper()
+	var body map[string]interface{}
+	if w.Header().Get("Content-Type") == msgpackContentType {
+		body = decodeMsgpack(t, w.Body.Bytes())
+	} else if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON %q: %v", w.Body.String(), err)
+	}
+	if data, ok := body["data"].(map[string]interface{}); ok {
+		delete(data, "processing_us")
+	}
+	body["newline"] = bytes.HasSuffix(w.Body.Bytes(), []byte("\n"))
+	return body
+}
+
+// TestPoolBuffersResponses tests that POOL_BUFFERS leaves every response the
+// same, so nothing from one request leaks into the next
+func TestPoolBuffersResponses(t *testing.T) {
+	requests := []struct {
+		name   string
+		body   string
+		accept string
+	}{
+		{"stats and mode", `{"message": "Hello", "mode": "upper", "stats": true}`, ""},
+		{"plain", `{"message": "hi"}`, ""},
+		{"invalid", `{"message": ""}`, ""},
+		{"msgpack stats", `{"message": "aaaa", "stats": true}`, "application/msgpack"},
+		{"msgpack plain", `{"message": "hi"}`, "application/msgpack"},
+	}
+
+	for _, compact := range []bool{false, true} {
+		fixed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		servers := make(map[bool]*Server)
+		for _, pooled := range []bool{false, true} {
+			cfg := DefaultConfig()
+			cfg.PoolBuffers = pooled
+			cfg.CompactJSON = compact
+			servers[pooled] = NewServer(cfg)
+			servers[pooled].now = func() time.Time { return fixed }
+		}
+
+		// Two rounds so the pooled server reuses what the first round returned
+		for round := 0; round < 2; round++ {
+			for _, tt := range requests {
+				bodies := make(map[bool]interface{})
+				for pooled, server := range servers {
+					req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(tt.body))
+					req.Header.Set("Content-Type", "application/json")
+					if tt.accept != "" {
+						req.Header.Set("Accept", tt.accept)
+					}
+					w := httptest.NewRecorder()
+					server.ServeHTTP(w, req)
+					bodies[pooled] = comparableBody(t, w)
+				}
+				if !reflect.DeepEqual(bodies[true], bodies[false]) {
+					t.Errorf("compact=%v round %d %s: pooled body %v differs from %v",
+						compact, round, tt.name, bodies[true], bodies[false])
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkEchoPoolBuffers compares allocations per echo request without
+// and with POOL_BUFFERS, for JSON and MessagePack responses
+func BenchmarkEchoPoolBuffers(b *testing.B) {
+	payload := `{"message": "benchmark test", "stats": true}`
+	formats := []struct {
+		name   string
+		accept string
+	}{
+		{"json", "application/json"},
+		{"msgpack", msgpackContentType},
+	}
+	for _, format := range formats {
+		for _, pooled := range []bool{false, true} {
+			name := format.name + "/unpooled"
+			if pooled {
+				name = format.name + "/pooled"
+			}
+			b.Run(name, func(b *testing.B) {
+				cfg := DefaultConfig()
+				cfg.PoolBuffers = pooled
+				server := NewServer(cfg)
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(payload))
+					req.Header.Set("Content-Type", "application/json")
+					req.Header.Set("Accept", format.accept)
+					w := httptest.NewRecorder()
+					server.ServeHTTP(w, req)
+				}
+			})
+		}
+	}
+}
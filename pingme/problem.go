@@ -0,0 +1,114 @@
+package pingme
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// problemContentType is the RFC 7807 media type for problem details
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object. Data carries the same
+// extra detail the envelope would, such as the allowed methods on a 405.
+type Problem struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty"`
+	Code     string      `json:"error_code,omitempty"`
+	Data     interface{} `json:"data,omitempty"`
+}
+
+// problemTypes maps the status codes the API returns to problem type URIs;
+// anything else is "about:blank", where the title alone describes the problem
+var problemTypes = map[int]string{
+	http.StatusBadRequest:            "urn:pingme:problem:invalid-request",
+	http.StatusUnauthorized:          "urn:pingme:problem:unauthorized",
+	http.StatusForbidden:             "urn:pingme:problem:forbidden",
+	http.StatusNotFound:              "urn:pingme:problem:not-found",
+	http.StatusMethodNotAllowed:      "urn:pingme:problem:method-not-allowed",
+	http.StatusRequestEntityTooLarge: "urn:pingme:problem:payload-too-large",
+	http.StatusRequestURITooLong:     "urn:pingme:problem:uri-too-long",
+	http.StatusUnsupportedMediaType:  "urn:pingme:problem:unsupported-media-type",
+	http.StatusTooManyRequests:       "urn:pingme:problem:rate-limited",
+	http.StatusInternalServerError:   "urn:pingme:problem:internal-error",
+	http.StatusServiceUnavailable:    "urn:pingme:problem:unavailable",
+}
+
+// problemType returns the type URI for a status code
+func problemType(statusCode int) string {
+	if t, ok := problemTypes[statusCode]; ok {
+		return t
+	}
+	return "about:blank"
+}
+
+// problemWriter marks a response whose errors should be sent as problem
+// details, remembering the request path for the instance member
+type problemWriter struct {
+	http.ResponseWriter
+	instance string
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *problemWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// problemTarget finds the problemWriter beneath any wrappers added after it
+func problemTarget(w http.ResponseWriter) (*problemWriter, bool) {
+	for {
+		switch t := w.(type) {
+		case *problemWriter:
+			return t, true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = t.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+}
+
+// wantsProblem reports whether the client's Accept header lists problem+json
+func wantsProblem(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err == nil && mediaType == problemContentType {
+			return true
+		}
+	}
+	return false
+}
+
+// problemDetails marks responses for clients accepting problem+json so
+// respondJSON sends their errors in that format
+func (s *Server) problemDetails(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wantsProblem(r) {
+			w = &problemWriter{ResponseWriter: w, instance: r.URL.Path}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// respondProblem sends an error response as RFC 7807 problem details
+func (s *Server) respondProblem(w http.ResponseWriter, statusCode int, instance string, response Response) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-API-Version", APIVersion)
+
+	w.WriteHeader(statusCode)
+	problem := Problem{
+		Type:     problemType(statusCode),
+		Title:    http.StatusText(statusCode),
+		Status:   statusCode,
+		Detail:   response.Error,
+		Instance: instance,
+		Code:     response.ErrorCode,
+		Data:     response.Data,
+	}
+	if err := s.encodeJSON(w, problem); err != nil {
+		s.logWriteError("Error encoding problem response", err)
+	}
+}
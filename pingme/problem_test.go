@@ -0,0 +1,93 @@
+package pingme
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestProblemJSONEmptyMessage tests that an empty-message error is sent as
+// RFC 7807 problem details when the client asks for them
+func TestProblemJSONEmptyMessage(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(`{"message": ""}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/problem+json")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/problem+json" {
+		t.Errorf("expected Content-Type application/problem+json, got %q", got)
+	}
+
+	var problem map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	expected := map[string]interface{}{
+		"type":       "urn:pingme:problem:invalid-request",
+		"title":      "Bad Request",
+		"status":     float64(http.StatusBadRequest),
+		"detail":     "Message field cannot be empty",
+		"instance":   "/echo",
+		"error_code": "empty_message",
+	}
+	for key, value := range expected {
+		if problem[key] != value {
+			t.Errorf("expected %s %v, got %v", key, value, problem[key])
+		}
+	}
+	if _, ok := problem["success"]; ok {
+		t.Error("expected no envelope fields in a problem response")
+	}
+}
+
+// TestProblemJSONData tests that envelope data travels with the problem
+func TestProblemJSONData(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPut, "/echo", nil)
+	req.Header.Set("Accept", "application/problem+json")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	var problem struct {
+		Type string               `json:"type"`
+		Data MethodNotAllowedData `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if problem.Type != "urn:pingme:problem:method-not-allowed" {
+		t.Errorf("expected method-not-allowed type, got %q", problem.Type)
+	}
+	if len(problem.Data.AllowedMethods) == 0 {
+		t.Error("expected allowed_methods in the problem data")
+	}
+}
+
+// TestProblemJSONDefault tests that errors keep the envelope unless problem+json is accepted
+// and that successes are unaffected by it
+func TestProblemJSONDefault(t *testing.T) {
+	post := func(message, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(`{"message": "`+message+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		newTestServer().ServeHTTP(w, req)
+		return w
+	}
+
+	if got := post("", "").Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("expected the JSON envelope by default, got %q", got)
+	}
+	if got := post("hi", "application/problem+json, application/json").Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("expected a success to use the JSON envelope, got %q", got)
+	}
+}
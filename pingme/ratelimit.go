@@ -0,0 +1,156 @@
+package pingme
+
+import (
+	"container/list"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimiter tracks a token bucket per client, keeping at most maxClients
+// buckets in least-recently-used order so memory stays bounded
+type rateLimiter struct {
+	mu         sync.Mutex
+	rate       float64
+	burst      float64
+	maxClients int
+	clients    map[string]*list.Element
+	lru        *list.List
+	now        func() time.Time
+}
+
+// clientBucket is the token bucket for a single client
+type clientBucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+}
+
+// rateDecision is the outcome of checking a request against the limiter
+type rateDecision struct {
+	allowed    bool
+	saturated  bool
+	retryAfter time.Duration
+
+	// limit, remaining and reset describe the client's bucket after this
+	// request: its capacity, whole tokens left, and time until it is full
+	limit     int
+	remaining int
+	reset     time.Duration
+}
+
+// newRateLimiter creates a limiter allowing rate requests per second per client with the given burst
+func newRateLimiter(rate float64, burst, maxClients int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:       rate,
+		burst:      float64(burst),
+		maxClients: maxClients,
+		clients:    make(map[string]*list.Element),
+		lru:        list.New(),
+		now:        time.Now,
+	}
+}
+
+// setLimits changes the rate, burst and client cap; existing buckets keep their tokens
+func (l *rateLimiter) setLimits(rate float64, burst, maxClients int) {
+	if burst < 1 {
+		burst = 1
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = rate
+	l.burst = float64(burst)
+	l.maxClients = maxClients
+}
+
+// refill tops up a bucket for the time elapsed since it was last used
+func (l *rateLimiter) refill(b *clientBucket, now time.Time) {
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+}
+
+// timeUntil returns how long a bucket needs to accumulate the given number of tokens
+func (l *rateLimiter) timeUntil(b *clientBucket, tokens float64) time.Duration {
+	return time.Duration((tokens - b.tokens) / l.rate * float64(time.Second))
+}
+
+// allow consumes a token for key, creating its bucket if there is room
+func (l *rateLimiter) allow(key string) rateDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	elem, ok := l.clients[key]
+	if !ok {
+		// Make room by evicting the least recently used client, but only if its
+		// bucket has fully refilled so that forgetting it loses no state
+		if l.maxClients > 0 && len(l.clients) >= l.maxClients {
+			oldest := l.lru.Back()
+			bucket := oldest.Value.(*clientBucket)
+			l.refill(bucket, now)
+			if bucket.tokens < l.burst {
+				return rateDecision{saturated: true, retryAfter: l.timeUntil(bucket, l.burst)}
+			}
+			l.lru.Remove(oldest)
+			delete(l.clients, bucket.key)
+		}
+		elem = l.lru.PushFront(&clientBucket{key: key, tokens: l.burst, last: now})
+		l.clients[key] = elem
+	}
+
+	l.lru.MoveToFront(elem)
+	bucket := elem.Value.(*clientBucket)
+	l.refill(bucket, now)
+	decision := rateDecision{limit: int(l.burst)}
+	if bucket.tokens < 1 {
+		decision.retryAfter = l.timeUntil(bucket, 1)
+	} else {
+		bucket.tokens--
+		decision.allowed = true
+	}
+	decision.remaining = int(bucket.tokens)
+	decision.reset = l.timeUntil(bucket, l.burst)
+	return decision
+}
+
+// retryAfterSeconds formats a wait as a whole number of seconds for Retry-After
+func retryAfterSeconds(d time.Duration) string {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.Itoa(seconds)
+}
+
+// rateLimit rejects clients that exceed Config.RateLimitRPS
+func (s *Server) rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.config().RateLimitRPS <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		decision := s.limiter.allow(s.clientIP(r))
+		if !decision.saturated {
+			h := w.Header()
+			h.Set("X-RateLimit-Limit", strconv.Itoa(decision.limit))
+			h.Set("X-RateLimit-Remaining", strconv.Itoa(decision.remaining))
+			h.Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(decision.reset.Seconds()))))
+		}
+		switch {
+		case decision.saturated:
+			w.Header().Set("Retry-After", retryAfterSeconds(decision.retryAfter))
+			s.respondError(w, newAPIError(http.StatusServiceUnavailable, codeOverloaded, "Server is tracking too many clients. Try again later."))
+			return
+		case !decision.allowed:
+			w.Header().Set("Retry-After", retryAfterSeconds(decision.retryAfter))
+			s.respondError(w, newAPIError(http.StatusTooManyRequests, codeRateLimited, "Rate limit exceeded. Try again later."))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,145 @@
+package pingme
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced time source for limiter tests
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+// newTestLimiter returns a limiter driven by a fake clock
+func newTestLimiter(rate float64, burst, maxClients int) (*rateLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 2, 15, 10, 30, 0, 0, time.UTC)}
+	limiter := newRateLimiter(rate, burst, maxClients)
+	limiter.now = clock.Now
+	return limiter, clock
+}
+
+// TestRateLimiterBurst tests that a client is throttled once its burst is spent
+func TestRateLimiterBurst(t *testing.T) {
+	limiter, clock := newTestLimiter(1, 2, 10)
+
+	for i := 0; i < 2; i++ {
+		if !limiter.allow("10.0.0.1").allowed {
+			t.Fatalf("expected request %d to be allowed", i+1)
+		}
+	}
+
+	decision := limiter.allow("10.0.0.1")
+	if decision.allowed {
+		t.Fatal("expected third request to be throttled")
+	}
+	if decision.retryAfter != time.Second {
+		t.Errorf("expected retry after 1s, got %v", decision.retryAfter)
+	}
+
+	clock.now = clock.now.Add(time.Second)
+	if !limiter.allow("10.0.0.1").allowed {
+		t.Error("expected request to be allowed after refill")
+	}
+}
+
+// TestRateLimiterSaturated tests that a new client gets rejected when every tracked client is active
+func TestRateLimiterSaturated(t *testing.T) {
+	limiter, _ := newTestLimiter(1, 1, 2)
+
+	limiter.allow("10.0.0.1")
+	limiter.allow("10.0.0.2")
+
+	decision := limiter.allow("10.0.0.3")
+	if !decision.saturated {
+		t.Fatal("expected new client to be rejected while the map is full")
+	}
+	if decision.retryAfter <= 0 {
+		t.Errorf("expected a positive retry-after, got %v", decision.retryAfter)
+	}
+	if len(limiter.clients) != 2 {
+		t.Errorf("expected 2 tracked clients, got %d", len(limiter.clients))
+	}
+}
+
+// TestRateLimiterEvictsIdle tests that the least recently used idle client makes room
+func TestRateLimiterEvictsIdle(t *testing.T) {
+	limiter, clock := newTestLimiter(1, 1, 2)
+
+	limiter.allow("10.0.0.1")
+	limiter.allow("10.0.0.2")
+
+	// Both buckets refill, so the oldest can be forgotten safely
+	clock.now = clock.now.Add(2 * time.Second)
+	limiter.allow("10.0.0.2")
+
+	if decision := limiter.allow("10.0.0.3"); !decision.allowed {
+		t.Fatalf("expected new client to be admitted after eviction, got %+v", decision)
+	}
+	if _, ok := limiter.clients["10.0.0.1"]; ok {
+		t.Error("expected least recently used client to be evicted")
+	}
+	if _, ok := limiter.clients["10.0.0.2"]; !ok {
+		t.Error("expected recently used client to be kept")
+	}
+}
+
+// TestRateLimitMiddleware tests the 429 and 503 responses through the server
+func TestRateLimitMiddleware(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RateLimitRPS = 0.001
+	cfg.RateLimitBurst = 1
+	cfg.RateLimitMaxClients = 1
+	server := NewServer(cfg)
+
+	send := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send("10.0.0.1:1234"); w.Code != http.StatusOK {
+		t.Fatalf("expected first request to succeed, got %d", w.Code)
+	}
+
+	if w := send("10.0.0.1:1234"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected status 429, got %d", w.Code)
+	}
+
+	w := send("10.0.0.2:1234")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503 for untracked client, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header on 503")
+	}
+}
+
+// TestRateLimitHeaders tests that every response reports the client's remaining budget
+func TestRateLimitHeaders(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RateLimitRPS = 0.001
+	cfg.RateLimitBurst = 3
+	server := NewServer(cfg)
+
+	for i, wantRemaining := range []string{"2", "1", "0"} {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "3" {
+			t.Errorf("request %d: expected X-RateLimit-Limit 3, got %q", i+1, got)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != wantRemaining {
+			t.Errorf("request %d: expected X-RateLimit-Remaining %s, got %q", i+1, wantRemaining, got)
+		}
+		if reset, err := strconv.Atoi(w.Header().Get("X-RateLimit-Reset")); err != nil || reset <= 0 {
+			t.Errorf("request %d: expected a positive X-RateLimit-Reset, got %q", i+1, w.Header().Get("X-RateLimit-Reset"))
+		}
+	}
+}
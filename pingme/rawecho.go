@@ -0,0 +1,78 @@
+package pingme
+
+import (
+	"io"
+	"net/http"
+)
+
+// redactedValue replaces credential header values in reflected requests
+const redactedValue = "[redacted]"
+
+// RawEchoData represents a request as it reached the server
+type RawEchoData struct {
+	Method  string              `json:"method"`
+	URL     string              `json:"url"`
+	Proto   string              `json:"proto"`
+	Host    string              `json:"host"`
+	Headers map[string][]string `json:"headers"`
+	Body    string              `json:"body"`
+	// Trailers holds trailer fields sent after a chunked body
+	Trailers map[string][]string `json:"trailers,omitempty"`
+}
+
+// requestURL rebuilds the absolute URL the client requested
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
+
+// reflectedHeaders copies header fields with credentials redacted. Fields
+// without values, such as trailers declared but never sent, are left out.
+func reflectedHeaders(h http.Header) map[string][]string {
+	headers := make(map[string][]string, len(h))
+	for name, values := range h {
+		if len(values) == 0 {
+			continue
+		}
+		headers[name] = append([]string(nil), values...)
+	}
+	for _, name := range credentialHeaders {
+		name = http.CanonicalHeaderKey(name)
+		if _, ok := headers[name]; ok {
+			headers[name] = []string{redactedValue}
+		}
+	}
+	return headers
+}
+
+// rawEchoHandler handles /echo/raw requests, reflecting the method, URL,
+// headers, body and trailers back so clients can see what arrives after any
+// proxies. r.Trailer is only filled in once the body has been read to EOF.
+func (s *Server) rawEchoHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.respondBodyError(w, err, "Error reading request body")
+		return
+	}
+	var trailers map[string][]string
+	if len(r.Trailer) > 0 {
+		trailers = reflectedHeaders(r.Trailer)
+	}
+
+	s.respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Request reflected successfully",
+		Data: RawEchoData{
+			Method:   r.Method,
+			URL:      requestURL(r),
+			Proto:    r.Proto,
+			Host:     r.Host,
+			Headers:  reflectedHeaders(r.Header),
+			Body:     string(body),
+			Trailers: trailers,
+		},
+	})
+}
@@ -0,0 +1,115 @@
+package pingme
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestRawEcho tests that /echo/raw reflects the request with credentials redacted
+func TestRawEcho(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPut, "http://api.example.com/echo/raw?debug=1", bytes.NewBufferString("hello=world"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Custom", "kept")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-API-Key", "secret")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Data RawEchoData `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	data := response.Data
+	if data.Method != http.MethodPut {
+		t.Errorf("expected method PUT, got %q", data.Method)
+	}
+	if data.URL != "http://api.example.com/echo/raw?debug=1" {
+		t.Errorf("expected the full URL, got %q", data.URL)
+	}
+	if data.Body != "hello=world" {
+		t.Errorf("expected body hello=world, got %q", data.Body)
+	}
+	if got := data.Headers["X-Custom"]; len(got) != 1 || got[0] != "kept" {
+		t.Errorf("expected X-Custom to be reflected, got %v", got)
+	}
+	for _, name := range []string{"Authorization", "X-Api-Key"} {
+		got := data.Headers[name]
+		if len(got) != 1 || got[0] != "[redacted]" {
+			t.Errorf("expected %s to be redacted, got %v", name, got)
+		}
+	}
+}
+
+// TestRawEchoBodyLimit tests that /echo/raw enforces MAX_BODY_BYTES
+func TestRawEchoBodyLimit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxBodyBytes = 16
+	req := httptest.NewRequest(http.MethodPost, "/echo/raw", strings.NewReader(strings.Repeat("x", 17)))
+	w := httptest.NewRecorder()
+
+	NewServer(cfg).ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status 413, got %d", w.Code)
+	}
+}
+
+// TestRawEchoTrailers tests that trailers sent after a chunked body are reflected, credentials redacted
+func TestRawEchoTrailers(t *testing.T) {
+	server := httptest.NewServer(newTestServer())
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		trailer  http.Header
+		expected map[string][]string
+	}{
+		{"sent", http.Header{"X-Checksum": {"abc123"}, "Authorization": {"Bearer secret"}}, map[string][]string{"X-Checksum": {"abc123"}, "Authorization": {"[redacted]"}}},
+		{"none", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// An unknown length forces a chunked body, which is where trailers go
+			req, err := http.NewRequest(http.MethodPost, server.URL+"/echo/raw", io.NopCloser(strings.NewReader("streamed")))
+			if err != nil {
+				t.Fatalf("failed to build request: %v", err)
+			}
+			req.ContentLength = -1
+			req.Trailer = tt.trailer
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer res.Body.Close()
+
+			var response struct {
+				Data RawEchoData `json:"data"`
+			}
+			if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Data.Body != "streamed" {
+				t.Errorf("expected body streamed, got %q", response.Data.Body)
+			}
+			if !reflect.DeepEqual(response.Data.Trailers, tt.expected) {
+				t.Errorf("expected trailers %v, got %v", tt.expected, response.Data.Trailers)
+			}
+		})
+	}
+}
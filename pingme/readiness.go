@@ -0,0 +1,37 @@
+package pingme
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// ReadinessData represents the data returned by the readiness endpoint
+type ReadinessData struct {
+	Ready bool `json:"ready"`
+	// WarmupRemainingSeconds is how much of Config.WarmupDuration is left; 0 once ready
+	WarmupRemainingSeconds float64 `json:"warmup_remaining_seconds"`
+}
+
+// readyHandler handles GET /readyz requests. The server reports not ready
+// until Config.WarmupDuration has passed since it started, measured on the
+// injected clock.
+func (s *Server) readyHandler(w http.ResponseWriter, r *http.Request) {
+	remaining := s.config().WarmupDuration - s.now().Sub(s.started)
+	if remaining > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(remaining.Seconds()))))
+		s.respondError(w, &apiError{
+			Status:  http.StatusServiceUnavailable,
+			Code:    codeNotReady,
+			Message: "Service is warming up",
+			Data:    ReadinessData{WarmupRemainingSeconds: remaining.Seconds()},
+		})
+		return
+	}
+
+	s.respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Service is ready",
+		Data:    ReadinessData{Ready: true},
+	})
+}
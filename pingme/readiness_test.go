@@ -0,0 +1,46 @@
+package pingme
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestReadinessWarmup tests that /readyz stays not ready until the warm-up has elapsed on the clock
+func TestReadinessWarmup(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.WarmupDuration = 3 * time.Second
+	server := NewServer(cfg)
+	server.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	started := time.Date(2024, 2, 15, 10, 30, 0, 0, time.UTC)
+	now := started
+	server.started = started
+	server.now = func() time.Time { return now }
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if got := w.Header().Get("Retry-After"); got != "3" {
+		t.Errorf("expected Retry-After 3, got %q", got)
+	}
+	assertError(t, w, http.StatusServiceUnavailable, codeNotReady)
+
+	now = started.Add(3 * time.Second)
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200 after the warm-up, got %d", w.Code)
+	}
+}
+
+// TestReadinessNoWarmup tests that /readyz is ready immediately by default
+func TestReadinessNoWarmup(t *testing.T) {
+	w := httptest.NewRecorder()
+	newTestServer().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+}
@@ -0,0 +1,52 @@
+package pingme
+
+import "net/http"
+
+// responseRecorder wraps a ResponseWriter to capture the status code and body
+// size, optionally running a hook just before the headers are sent
+type responseRecorder struct {
+	http.ResponseWriter
+	status       int
+	bytes        int
+	beforeHeader func(h http.Header)
+}
+
+// newResponseRecorder wraps w; beforeHeader may be nil
+func newResponseRecorder(w http.ResponseWriter, beforeHeader func(h http.Header)) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w, beforeHeader: beforeHeader}
+}
+
+// WriteHeader records the status and runs the header hook once
+func (w *responseRecorder) WriteHeader(statusCode int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = statusCode
+	if w.beforeHeader != nil {
+		w.beforeHeader(w.Header())
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write sends an implicit 200 if no status was set, then counts the bytes written
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Status returns the response status, defaulting to 200 when nothing was written
+func (w *responseRecorder) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
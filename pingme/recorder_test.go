@@ -0,0 +1,34 @@
+package pingme
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestResponseRecorder tests status and size capture with the header hook running once
+func TestResponseRecorder(t *testing.T) {
+	calls := 0
+	rec := newResponseRecorder(httptest.NewRecorder(), func(h http.Header) {
+		calls++
+		h.Set("X-Hook", "ran")
+	})
+
+	if _, err := rec.Write([]byte("hello")); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	rec.WriteHeader(http.StatusTeapot)
+
+	if rec.Status() != http.StatusOK {
+		t.Errorf("expected implicit status 200, got %d", rec.Status())
+	}
+	if rec.bytes != 5 {
+		t.Errorf("expected 5 bytes recorded, got %d", rec.bytes)
+	}
+	if calls != 1 {
+		t.Errorf("expected header hook to run once, ran %d times", calls)
+	}
+	if rec.Header().Get("X-Hook") != "ran" {
+		t.Error("expected header set by hook")
+	}
+}
@@ -0,0 +1,219 @@
+package pingme
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"slices"
+)
+
+// ReloadResult reports which settings a reload applied and which changed
+// but only take effect after a restart
+type ReloadResult struct {
+	Applied         []string `json:"applied"`
+	RestartRequired []string `json:"restart_required"`
+}
+
+// setting names a Config field by its environment variable and reports whether it differs
+type setting struct {
+	name    string
+	changed func(a, b *Config) bool
+}
+
+// hotSettings are copied onto the running server by Reload
+var hotSettings = []setting{
+	{"GREETING", func(a, b *Config) bool { return a.Greeting != b.Greeting }},
+	{"ECHO_PREFIX", func(a, b *Config) bool { return a.EchoPrefix != b.EchoPrefix }},
+	{"LOG_LEVEL", func(a, b *Config) bool { return a.LogLevel != b.LogLevel }},
+	{"LOG_SAMPLE_RATE", func(a, b *Config) bool { return a.LogSampleRate != b.LogSampleRate }},
+	{"HEALTH_TIMEOUT", func(a, b *Config) bool { return a.HealthTimeout != b.HealthTimeout }},
+	{"SLOW_REQUEST_THRESHOLD", func(a, b *Config) bool { return a.SlowRequestThreshold != b.SlowRequestThreshold }},
+	{"RATE_LIMIT_RPS", func(a, b *Config) bool { return a.RateLimitRPS != b.RateLimitRPS }},
+	{"RATE_LIMIT_BURST", func(a, b *Config) bool { return a.RateLimitBurst != b.RateLimitBurst }},
+	{"RATE_LIMIT_MAX_CLIENTS", func(a, b *Config) bool { return a.RateLimitMaxClients != b.RateLimitMaxClients }},
+}
+
+// coldSettings are fixed when the server and listener are built
+var coldSettings = []setting{
+	{"BIND", func(a, b *Config) bool { return a.Bind != b.Bind }},
+	{"CONN_IDLE_TIMEOUT", func(a, b *Config) bool { return a.IdleTimeout != b.IdleTimeout }},
+	{"DISABLE_KEEPALIVE", func(a, b *Config) bool { return a.DisableKeepAlive != b.DisableKeepAlive }},
+	{"REUSE_PORT", func(a, b *Config) bool { return a.ReusePort != b.ReusePort }},
+	{"PORT", func(a, b *Config) bool { return a.Port != b.Port }},
+	{"ENABLE_GRPC", func(a, b *Config) bool { return a.EnableGRPC != b.EnableGRPC }},
+	{"GRPC_PORT", func(a, b *Config) bool { return a.GRPCPort != b.GRPCPort }},
+	{"READ_TIMEOUT", func(a, b *Config) bool { return a.ReadTimeout != b.ReadTimeout }},
+	{"READ_HEADER_TIMEOUT", func(a, b *Config) bool { return a.ReadHeaderTimeout != b.ReadHeaderTimeout }},
+	{"WRITE_TIMEOUT", func(a, b *Config) bool { return a.WriteTimeout != b.WriteTimeout }},
+	{"SHUTDOWN_TIMEOUT", func(a, b *Config) bool { return a.ShutdownTimeout != b.ShutdownTimeout }},
+	{"METRICS_PUSH_URL", func(a, b *Config) bool { return a.MetricsPushURL != b.MetricsPushURL }},
+	{"METRICS_PUSH_INTERVAL", func(a, b *Config) bool { return a.MetricsPushInterval != b.MetricsPushInterval }},
+	{"WARMUP_DURATION", func(a, b *Config) bool { return a.WarmupDuration != b.WarmupDuration }},
+	{"HANDLER_TIMEOUT", func(a, b *Config) bool { return a.HandlerTimeout != b.HandlerTimeout }},
+	{"ECHO_TIMEOUT", routeTimeoutChanged("/echo")},
+	{"ECHO_FILE_TIMEOUT", routeTimeoutChanged("/echo/file")},
+	{"ECHO_NDJSON_TIMEOUT", routeTimeoutChanged("/echo/ndjson")},
+	{"HEALTHZ_TIMEOUT", routeTimeoutChanged("/healthz")},
+	{"GREETING_CACHE_CONTROL", cacheControlChanged("/")},
+	{"VERSION_CACHE_CONTROL", cacheControlChanged("/version")},
+	{"ECHO_CACHE_CONTROL", cacheControlChanged("/echo")},
+	{"LOG_FILE", func(a, b *Config) bool { return a.LogFile != b.LogFile }},
+	{"LOG_FORMAT", func(a, b *Config) bool { return a.LogFormat != b.LogFormat }},
+	{"DISABLE_GREETING", func(a, b *Config) bool { return a.DisableGreeting != b.DisableGreeting }},
+	{"ENABLE_ECHO", func(a, b *Config) bool { return a.DisableEcho != b.DisableEcho }},
+	{"ADMIN_API_KEY", func(a, b *Config) bool { return a.AdminAPIKey != b.AdminAPIKey }},
+	{"RESPONSE_SIGNING_KEY", func(a, b *Config) bool { return a.ResponseSigningKey != b.ResponseSigningKey }},
+	{"JSON_CASE", func(a, b *Config) bool { return a.JSONCase != b.JSONCase }},
+	{"STRICT_ENVELOPE", func(a, b *Config) bool { return a.StrictEnvelope != b.StrictEnvelope }},
+	{"COMPACT_JSON", func(a, b *Config) bool { return a.CompactJSON != b.CompactJSON }},
+	{"POOL_BUFFERS", func(a, b *Config) bool { return a.PoolBuffers != b.PoolBuffers }},
+	{"STRICT_UTF8", func(a, b *Config) bool { return a.StrictUTF8 != b.StrictUTF8 }},
+	{"RESPONSE_WRAPPER", func(a, b *Config) bool { return a.ResponseWrapper != b.ResponseWrapper }},
+	{"MAX_MESSAGE_LENGTH", func(a, b *Config) bool { return a.MaxMessageLength != b.MaxMessageLength }},
+	{"MAX_BODY_BYTES", func(a, b *Config) bool { return a.MaxBodyBytes != b.MaxBodyBytes }},
+	{"MAX_JSON_DEPTH", func(a, b *Config) bool { return a.MaxJSONDepth != b.MaxJSONDepth }},
+	{"MAX_JSON_TOKENS", func(a, b *Config) bool { return a.MaxJSONTokens != b.MaxJSONTokens }},
+	{"MAX_FILE_BYTES", func(a, b *Config) bool { return a.MaxFileBytes != b.MaxFileBytes }},
+	{"MAX_URL_LENGTH", func(a, b *Config) bool { return a.MaxURLLength != b.MaxURLLength }},
+	{"MAX_HEADER_COUNT", func(a, b *Config) bool { return a.MaxHeaderCount != b.MaxHeaderCount }},
+	{"PATH_PREFIX", func(a, b *Config) bool { return a.PathPrefix != b.PathPrefix }},
+	{"DEBUG_LOG_BODIES", func(a, b *Config) bool { return a.DebugLogBodies != b.DebugLogBodies }},
+	{"TRUST_PROXY", func(a, b *Config) bool { return a.TrustProxy != b.TrustProxy }},
+	{"TRUSTED_PROXIES", func(a, b *Config) bool { return !slices.Equal(a.TrustedProxies, b.TrustedProxies) }},
+	{"LOG_CONN_STATE", func(a, b *Config) bool { return a.LogConnState != b.LogConnState }},
+	{"GZIP", func(a, b *Config) bool { return a.Gzip != b.Gzip }},
+	{"DISABLE_MIDDLEWARE", func(a, b *Config) bool { return !slices.Equal(a.DisabledMiddleware, b.DisabledMiddleware) }},
+	{"MAX_CONCURRENT", func(a, b *Config) bool { return a.MaxConcurrent != b.MaxConcurrent }},
+	{"CORS_ALLOWED_ORIGINS", func(a, b *Config) bool { return !slices.Equal(a.CORSAllowedOrigins, b.CORSAllowedOrigins) }},
+	{"CORS_MAX_AGE", func(a, b *Config) bool { return a.CORSMaxAge != b.CORSMaxAge }},
+	{"ALLOW_CREDENTIALS", func(a, b *Config) bool { return a.AllowCredentials != b.AllowCredentials }},
+	{"IDEMPOTENCY_TTL", func(a, b *Config) bool { return a.IdempotencyTTL != b.IdempotencyTTL }},
+	{"IDEMPOTENCY_MAX_KEYS", func(a, b *Config) bool { return a.IdempotencyMaxKeys != b.IdempotencyMaxKeys }},
+	{"ECHO_HISTORY_SIZE", func(a, b *Config) bool { return a.EchoHistorySize != b.EchoHistorySize }},
+}
+
+// routeTimeoutChanged compares one path's entry in Config.RouteTimeouts
+func routeTimeoutChanged(path string) func(a, b *Config) bool {
+	return func(a, b *Config) bool {
+		timeoutA, okA := a.RouteTimeouts[path]
+		timeoutB, okB := b.RouteTimeouts[path]
+		return okA != okB || timeoutA != timeoutB
+	}
+}
+
+// cacheControlChanged compares one path's entry in Config.CacheControl
+func cacheControlChanged(path string) func(a, b *Config) bool {
+	return func(a, b *Config) bool {
+		return a.CacheControl[path] != b.CacheControl[path]
+	}
+}
+
+// Reload applies the hot-reloadable settings from cfg to the running server
+// and reports any other settings in cfg that differ from the current ones.
+// If cfg fails Validate nothing is applied and the *ConfigError is returned.
+func (s *Server) Reload(cfg Config) (ReloadResult, error) {
+	if err := cfg.Validate(); err != nil {
+		return ReloadResult{}, err
+	}
+	current := s.config()
+	result := ReloadResult{Applied: []string{}, RestartRequired: []string{}}
+	for _, st := range hotSettings {
+		if st.changed(current, &cfg) {
+			result.Applied = append(result.Applied, st.name)
+		}
+	}
+	for _, st := range coldSettings {
+		if st.changed(current, &cfg) {
+			result.RestartRequired = append(result.RestartRequired, st.name)
+		}
+	}
+
+	// A toggle from POST /admin/maintenance survives reloads, such as the
+	// SIGHUP sent for log rotation, until MAINTENANCE_MODE itself changes
+	maintenanceChanged := s.configuredMaintenance.Swap(cfg.MaintenanceMode) != cfg.MaintenanceMode
+	if maintenanceChanged && current.MaintenanceMode != cfg.MaintenanceMode {
+		result.Applied = append(result.Applied, "MAINTENANCE_MODE")
+	}
+
+	next := s.updateConfig(func(next *Config) {
+		next.Greeting = cfg.Greeting
+		next.EchoPrefix = cfg.EchoPrefix
+		next.LogLevel = cfg.LogLevel
+		next.LogSampleRate = cfg.LogSampleRate
+		next.HealthTimeout = cfg.HealthTimeout
+		next.SlowRequestThreshold = cfg.SlowRequestThreshold
+		next.RateLimitRPS = cfg.RateLimitRPS
+		next.RateLimitBurst = cfg.RateLimitBurst
+		next.RateLimitMaxClients = cfg.RateLimitMaxClients
+		if maintenanceChanged {
+			next.MaintenanceMode = cfg.MaintenanceMode
+		}
+	})
+	s.limiter.setLimits(next.RateLimitRPS, next.RateLimitBurst, next.RateLimitMaxClients)
+	s.logLevel.Set(parseLogLevel(next.LogLevel))
+	return result, nil
+}
+
+// SetReloadOverrides registers apply to adjust every config ReloadFromEnv
+// reads before it is applied, so settings given other than through the
+// environment keep precedence. Call it before the server starts serving.
+func (s *Server) SetReloadOverrides(apply func(*Config)) {
+	s.reloadOverrides = apply
+}
+
+// ReloadFromEnv re-reads the environment with LoadConfig, applies any
+// SetReloadOverrides on top and applies the result via Reload. PORT keeps
+// its running value unless it is set in the environment or overridden.
+func (s *Server) ReloadFromEnv() (ReloadResult, error) {
+	cfg := LoadConfig()
+	cfg.Port = getenv("PORT", s.config().Port)
+	if s.reloadOverrides != nil {
+		s.reloadOverrides(&cfg)
+	}
+	result, err := s.Reload(cfg)
+	if err != nil {
+		s.logger.Error("Configuration reload rejected; keeping the running settings", "error", err)
+		return result, err
+	}
+	s.logger.Info("Configuration reloaded", "applied", result.Applied, "restart_required", result.RestartRequired)
+	return result, nil
+}
+
+// requireAdminKey reports whether the request carries the configured admin
+// API key, responding with an error if it does not
+func (s *Server) requireAdminKey(w http.ResponseWriter, r *http.Request) bool {
+	key := s.config().AdminAPIKey
+	if key == "" {
+		s.respondError(w, newAPIError(http.StatusForbidden, codeAdminDisabled, "Admin endpoints are disabled. Set ADMIN_API_KEY to enable them."))
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-API-Key")), []byte(key)) != 1 {
+		s.respondError(w, newAPIError(http.StatusUnauthorized, codeUnauthorized, "Missing or invalid X-API-Key header"))
+		return false
+	}
+	return true
+}
+
+// reloadHandler handles POST /admin/reload by re-reading the environment
+func (s *Server) reloadHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdminKey(w, r) {
+		return
+	}
+	result, err := s.ReloadFromEnv()
+	var cfgErr *ConfigError
+	if errors.As(err, &cfgErr) {
+		apiErr := newAPIError(http.StatusUnprocessableEntity, codeInvalidConfig, "Configuration is invalid; nothing was reloaded")
+		apiErr.Data = map[string][]string{"problems": cfgErr.Problems}
+		s.respondError(w, apiErr)
+		return
+	}
+	if err != nil {
+		s.respondError(w, err)
+		return
+	}
+	s.respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Configuration reloaded",
+		Data:    result,
+	})
+}
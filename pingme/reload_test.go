@@ -0,0 +1,214 @@
+package pingme
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// getGreeting fetches GET / and returns the greeting text
+func getGreeting(t *testing.T, server *Server) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var response struct {
+		Data GreetingData `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode greeting: %v", err)
+	}
+	return response.Data.Greeting
+}
+
+// TestReloadGreeting tests that /admin/reload picks up a changed GREETING
+func TestReloadGreeting(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AdminAPIKey = "secret"
+	server := NewServer(cfg)
+
+	if got := getGreeting(t, server); got != "Welcome to PingMe API!" {
+		t.Fatalf("expected default greeting, got %q", got)
+	}
+
+	t.Setenv("GREETING", "Hello again!")
+	t.Setenv("ADMIN_API_KEY", "secret")
+	req := httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+	req.Header.Set("X-API-Key", "secret")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Data ReloadResult `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Data.Applied) != 1 || response.Data.Applied[0] != "GREETING" {
+		t.Errorf("expected applied [GREETING], got %v", response.Data.Applied)
+	}
+
+	if got := getGreeting(t, server); got != "Hello again!" {
+		t.Errorf("expected reloaded greeting, got %q", got)
+	}
+}
+
+// TestReloadRestartRequired tests that listener settings are reported rather than applied
+func TestReloadRestartRequired(t *testing.T) {
+	server := newTestServer()
+
+	cfg := DefaultConfig()
+	cfg.Port = "9090"
+	cfg.RateLimitRPS = 5
+	result, err := server.Reload(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Applied) != 1 || result.Applied[0] != "RATE_LIMIT_RPS" {
+		t.Errorf("expected applied [RATE_LIMIT_RPS], got %v", result.Applied)
+	}
+	if len(result.RestartRequired) != 1 || result.RestartRequired[0] != "PORT" {
+		t.Errorf("expected restart required [PORT], got %v", result.RestartRequired)
+	}
+	if got := server.config().Port; got != "8080" {
+		t.Errorf("expected port to stay 8080, got %q", got)
+	}
+	if got := server.config().RateLimitRPS; got != 5 {
+		t.Errorf("expected rate limit 5, got %v", got)
+	}
+}
+
+// TestReloadRejectsInvalid tests that an invalid config is refused with nothing applied
+func TestReloadRejectsInvalid(t *testing.T) {
+	server := newTestServer()
+
+	cfg := DefaultConfig()
+	cfg.Greeting = "Changed"
+	cfg.RateLimitRPS = -1
+	cfg.LogLevel = "loud"
+	_, err := server.Reload(cfg)
+	var cfgErr *ConfigError
+	if !errors.As(err, &cfgErr) {
+		t.Fatalf("expected a *ConfigError, got %v", err)
+	}
+	if len(cfgErr.Problems) != 2 {
+		t.Errorf("expected 2 problems, got %v", cfgErr.Problems)
+	}
+	if got := getGreeting(t, server); got != "Welcome to PingMe API!" {
+		t.Errorf("expected the greeting to stay unchanged, got %q", got)
+	}
+	if got := server.config().RateLimitRPS; got == -1 {
+		t.Error("expected the invalid rate limit not to be applied")
+	}
+}
+
+// TestReloadHandlerInvalid tests that /admin/reload reports an invalid environment
+func TestReloadHandlerInvalid(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AdminAPIKey = "secret"
+	server := NewServer(cfg)
+
+	t.Setenv("ADMIN_API_KEY", "secret")
+	t.Setenv("HEALTH_TIMEOUT", "-1s")
+	req := httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+	req.Header.Set("X-API-Key", "secret")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	if !strings.Contains(w.Body.String(), "HEALTH_TIMEOUT") {
+		t.Errorf("expected the problem to name HEALTH_TIMEOUT, got %s", w.Body.String())
+	}
+	assertError(t, w, http.StatusUnprocessableEntity, codeInvalidConfig)
+}
+
+// TestReloadRequiresAPIKey tests that /admin/reload rejects missing or wrong keys
+func TestReloadRequiresAPIKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		adminKey   string
+		headerKey  string
+		wantStatus int
+	}{
+		{"disabled", "", "anything", http.StatusForbidden},
+		{"missing key", "secret", "", http.StatusUnauthorized},
+		{"wrong key", "secret", "guess", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.AdminAPIKey = tt.adminKey
+			req := httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+			if tt.headerKey != "" {
+				req.Header.Set("X-API-Key", tt.headerKey)
+			}
+			w := httptest.NewRecorder()
+
+			NewServer(cfg).ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
+
+// TestReloadLogLevelPerServer tests that reloading LOG_LEVEL on one server
+// leaves the level of another server in the same process alone
+func TestReloadLogLevelPerServer(t *testing.T) {
+	reloaded := NewServer(DefaultConfig())
+	other := NewServer(DefaultConfig())
+
+	cfg := DefaultConfig()
+	cfg.LogLevel = "debug"
+	if _, err := reloaded.Reload(cfg); err != nil {
+		t.Fatalf("unexpected reload error: %v", err)
+	}
+
+	ctx := context.Background()
+	if !reloaded.logger.Enabled(ctx, slog.LevelDebug) {
+		t.Error("expected the reloaded server to log at debug level")
+	}
+	if other.logger.Enabled(ctx, slog.LevelDebug) {
+		t.Error("expected the other server to stay at info level")
+	}
+}
+
+// TestReloadConcurrentMaintenance tests that a maintenance toggle racing a
+// reload is kept along with the reloaded settings
+func TestReloadConcurrentMaintenance(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		server := NewServer(DefaultConfig())
+		cfg := DefaultConfig()
+		cfg.Greeting = "Reloaded"
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			server.setMaintenance(true)
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := server.Reload(cfg); err != nil {
+				t.Errorf("unexpected reload error: %v", err)
+			}
+		}()
+		wg.Wait()
+
+		if got := server.config(); !got.MaintenanceMode || got.Greeting != "Reloaded" {
+			t.Fatalf("expected maintenance on and the reloaded greeting, got %v and %q", got.MaintenanceMode, got.Greeting)
+		}
+	}
+}
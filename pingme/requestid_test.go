@@ -0,0 +1,67 @@
+package pingme
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+// uuidPattern matches a version 4 UUID
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// TestRequestIDGenerated tests that requests without an ID get a fresh UUID
+func TestRequestIDGenerated(t *testing.T) {
+	w := httptest.NewRecorder()
+	newTestServer().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if id := w.Header().Get(requestIDHeader); !uuidPattern.MatchString(id) {
+		t.Errorf("expected a generated UUID, got %q", id)
+	}
+}
+
+// TestRequestIDPropagated tests that well-formed incoming IDs are reused and bad ones replaced
+func TestRequestIDPropagated(t *testing.T) {
+	tests := []struct {
+		incoming string
+		reused   bool
+	}{
+		{"abc-123", true},
+		{"bad id\r\ninjected", false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		req.Header.Set(requestIDHeader, tt.incoming)
+		w := httptest.NewRecorder()
+
+		newTestServer().ServeHTTP(w, req)
+
+		id := w.Header().Get(requestIDHeader)
+		if tt.reused && id != tt.incoming {
+			t.Errorf("expected incoming ID %q to be reused, got %q", tt.incoming, id)
+		}
+		if !tt.reused && !uuidPattern.MatchString(id) {
+			t.Errorf("expected invalid ID %q to be replaced, got %q", tt.incoming, id)
+		}
+	}
+}
+
+// TestRequestIDGenerator tests that an injected generator supplies deterministic IDs
+func TestRequestIDGenerator(t *testing.T) {
+	server := newTestServer()
+	next := 0
+	server.idGen = func() string {
+		next++
+		return fmt.Sprintf("test-%d", next)
+	}
+
+	for _, expected := range []string{"test-1", "test-2"} {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if got := w.Header().Get(requestIDHeader); got != expected {
+			t.Errorf("expected %s %q, got %q", requestIDHeader, expected, got)
+		}
+	}
+}
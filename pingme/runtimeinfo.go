@@ -0,0 +1,42 @@
+package pingme
+
+import (
+	"net/http"
+	"runtime"
+)
+
+// RuntimeData represents the data returned by the admin runtime endpoint
+type RuntimeData struct {
+	Goroutines     int    `json:"goroutines"`
+	GOMAXPROCS     int    `json:"gomaxprocs"`
+	HeapAllocBytes uint64 `json:"heap_alloc_bytes"`
+	HeapSysBytes   uint64 `json:"heap_sys_bytes"`
+	HeapObjects    uint64 `json:"heap_objects"`
+	NumGC          uint32 `json:"num_gc"`
+	// PauseTotalNs is the cumulative stop-the-world GC pause time
+	PauseTotalNs uint64 `json:"gc_pause_total_ns"`
+}
+
+// runtimeHandler handles GET /admin/runtime. ReadMemStats briefly stops the
+// world, which is why it sits behind the admin key rather than in /stats.
+func (s *Server) runtimeHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdminKey(w, r) {
+		return
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	s.respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Runtime statistics retrieved successfully",
+		Data: RuntimeData{
+			Goroutines:     runtime.NumGoroutine(),
+			GOMAXPROCS:     runtime.GOMAXPROCS(0),
+			HeapAllocBytes: mem.HeapAlloc,
+			HeapSysBytes:   mem.HeapSys,
+			HeapObjects:    mem.HeapObjects,
+			NumGC:          mem.NumGC,
+			PauseTotalNs:   mem.PauseTotalNs,
+		},
+	})
+}
@@ -0,0 +1,36 @@
+package pingme
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRuntimeHandler tests that /admin/runtime reports live goroutine and scheduler counts
+func TestRuntimeHandler(t *testing.T) {
+	w := serve(newMaintenanceServer(false), http.MethodGet, "/admin/runtime", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Data RuntimeData `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Data.Goroutines <= 0 {
+		t.Errorf("expected a positive goroutine count, got %d", response.Data.Goroutines)
+	}
+	if response.Data.GOMAXPROCS <= 0 || response.Data.HeapAllocBytes == 0 {
+		t.Errorf("expected GOMAXPROCS and heap stats, got %+v", response.Data)
+	}
+}
+
+// TestRuntimeHandlerRequiresKey tests that /admin/runtime is refused without the admin key
+func TestRuntimeHandlerRequiresKey(t *testing.T) {
+	w := httptest.NewRecorder()
+	newMaintenanceServer(false).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/runtime", nil))
+	assertError(t, w, http.StatusUnauthorized, codeUnauthorized)
+}
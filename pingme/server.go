@@ -0,0 +1,307 @@
+package pingme
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Server is a configured PingMe API instance with all routes registered
+type Server struct {
+	cfg     atomic.Pointer[Config]
+	logger  *slog.Logger
+	mux     *http.ServeMux
+	handler http.Handler
+	latency *latencyTracker
+	metrics metrics
+	conns   connCounters
+	limiter *rateLimiter
+	slots   chan struct{}
+
+	// logLevel gates logger at Config.LogLevel, separately for each Server
+	// so reloading one embedded Server leaves the others alone
+	logLevel slog.LevelVar
+
+	// idempotency is nil when IdempotencyTTL disables replaying
+	idempotency *idempotencyCache
+	// history is nil when EchoHistorySize disables recording
+	history *echoHistory
+
+	// trustedProxies is Config.TrustedProxies parsed once at startup
+	trustedProxies []netip.Prefix
+
+	routeTimeouts map[string]time.Duration
+	cacheControl  map[string]string
+
+	checksMu sync.RWMutex
+	checks   []namedCheck
+
+	shutdownMu    sync.Mutex
+	shutdownHooks []ShutdownHook
+
+	// configuredMaintenance is MaintenanceMode as last set by the config
+	// rather than POST /admin/maintenance
+	configuredMaintenance atomic.Bool
+
+	// reloadOverrides reapplies settings from outside the environment, such
+	// as command-line flags, to each config ReloadFromEnv reads
+	reloadOverrides func(*Config)
+
+	// now is the clock handlers read; tests replace it with a fixed time
+	now     func() time.Time
+	started time.Time
+
+	// idGen generates request IDs; tests replace it with a fixed sequence
+	idGen func() string
+}
+
+// NewServer builds a Server for the given configuration. It logs through
+// slog.Default at its own LogLevel, so install a logger from NewLogger
+// first to apply LogFormat. When MetricsPushURL is set it starts pushing
+// metrics in the background until Shutdown is called.
+func NewServer(cfg Config) *Server {
+	s := &Server{
+		mux:     http.NewServeMux(),
+		latency: newLatencyTracker(latencyReservoirSize),
+		limiter: newRateLimiter(cfg.RateLimitRPS, cfg.RateLimitBurst, cfg.RateLimitMaxClients),
+		now:     time.Now,
+		idGen:   newRequestID,
+	}
+	s.logLevel.Set(parseLogLevel(cfg.LogLevel))
+	s.logger = slog.New(&levelHandler{level: &s.logLevel, Handler: slog.Default().Handler()})
+	s.started = s.now()
+	s.cfg.Store(&cfg)
+	s.configuredMaintenance.Store(cfg.MaintenanceMode)
+	// Validate rejects bad entries before the server is built
+	s.trustedProxies, _ = parseTrustedProxies(cfg.TrustedProxies)
+	s.routeTimeouts = maps.Clone(cfg.RouteTimeouts)
+	s.cacheControl = maps.Clone(cfg.CacheControl)
+	if cfg.MaxConcurrent > 0 {
+		s.slots = make(chan struct{}, cfg.MaxConcurrent)
+	}
+	if cfg.IdempotencyTTL > 0 {
+		s.idempotency = newIdempotencyCache(cfg.IdempotencyTTL, cfg.IdempotencyMaxKeys)
+	}
+	if cfg.EchoHistorySize > 0 {
+		s.history = newEchoHistory(cfg.EchoHistorySize)
+	}
+	s.RegisterHealthCheck("self", HealthCheckFunc(func(context.Context) error { return nil }))
+	s.routes()
+	s.handler = buildChain(s.mux, &cfg, s.middlewareRegistry())
+	s.startMetricsPusher(&cfg)
+	return s
+}
+
+// config returns the current configuration, which Reload may swap at any time
+func (s *Server) config() *Config {
+	return s.cfg.Load()
+}
+
+// updateConfig swaps in a copy of the current configuration with change
+// applied, retrying if another update got there first, so Reload and
+// setMaintenance never overwrite each other's changes
+func (s *Server) updateConfig(change func(*Config)) *Config {
+	for {
+		current := s.cfg.Load()
+		next := *current
+		change(&next)
+		if s.cfg.CompareAndSwap(current, &next) {
+			return &next
+		}
+	}
+}
+
+// route is a single method and path served by the API
+type route struct {
+	method      string
+	path        string
+	handler     http.HandlerFunc
+	description string
+}
+
+// routeTable lists every endpoint the server exposes. It is the single
+// source for both the mux and GET /_routes, so routes left out by
+// DisableGreeting or DisableEcho answer 404.
+func (s *Server) routeTable() []route {
+	cfg := s.config()
+	var routes []route
+	if !cfg.DisableGreeting {
+		routes = append(routes, route{http.MethodGet, "/{$}", s.greetingHandler, "Greeting with server timestamp"})
+	}
+	routes = append(routes, []route{
+		{http.MethodGet, "/greet/{name}", s.namedGreetingHandler, "Named greetings"},
+		{http.MethodGet, "/config", s.configHandler, "Effective configuration (requires ADMIN_API_KEY)"},
+		{http.MethodGet, "/healthz", s.healthHandler, "Health check"},
+		{http.MethodGet, "/readyz", s.readyHandler, "Readiness check"},
+	}...)
+	if !cfg.DisableEcho {
+		routes = append(routes, s.echoRoutes()...)
+	}
+	return append(routes, []route{
+		{http.MethodGet, "/metrics", s.metricsHandler, "Prometheus metrics"},
+		{http.MethodGet, "/stats", s.statsHandler, "Connection state counters"},
+		{http.MethodGet, "/stats/latency", s.latencyStatsHandler, "Latency percentiles"},
+		{http.MethodGet, "/time", s.timeHandler, "Server time in several formats"},
+		{http.MethodGet, "/version", s.versionHandler, "Build information"},
+		{http.MethodGet, "/whoami", s.whoamiHandler, "Client IP and user agent"},
+		{http.MethodGet, "/assets/", s.assetsHandler(), "Embedded static files"},
+		{http.MethodGet, "/_routes", s.routesHandler, "List every route"},
+		{http.MethodGet, "/admin/runtime", s.runtimeHandler, "Goroutine and memory statistics (requires ADMIN_API_KEY)"},
+		{http.MethodPost, "/admin/reload", s.reloadHandler, "Reload configuration (requires ADMIN_API_KEY)"},
+		{http.MethodPost, "/admin/maintenance", s.maintenanceHandler, "Toggle maintenance mode (requires ADMIN_API_KEY)"},
+	}...)
+}
+
+// echoRoutes lists the /echo family, which DisableEcho removes as a group
+func (s *Server) echoRoutes() []route {
+	return []route{
+		{http.MethodGet, "/echo", s.signed(s.echoQueryHandler), "Echo a message, optionally transformed"},
+		{http.MethodPost, "/echo", s.signed(s.idempotent(s.echoHandler)), "Echo a message, optionally transformed"},
+		{http.MethodPost, "/echo/file", s.fileEchoHandler, "File upload metadata"},
+		{http.MethodPost, "/echo/ndjson", s.ndjsonEchoHandler, "Streaming batch echo"},
+		{http.MethodGet, "/echo/history/{id}", s.echoHistoryHandler, "Fetch a recorded echo"},
+		{http.MethodGet, "/echo/raw", s.rawEchoHandler, "Reflect the raw request"},
+		{http.MethodPost, "/echo/raw", s.rawEchoHandler, "Reflect the raw request"},
+		{http.MethodPut, "/echo/raw", s.rawEchoHandler, "Reflect the raw request"},
+		{http.MethodPatch, "/echo/raw", s.rawEchoHandler, "Reflect the raw request"},
+		{http.MethodDelete, "/echo/raw", s.rawEchoHandler, "Reflect the raw request"},
+	}
+}
+
+// RouteInfo describes one path served by the API
+type RouteInfo struct {
+	Path        string   `json:"path"`
+	Methods     []string `json:"methods"`
+	Description string   `json:"description"`
+}
+
+// Routes lists the paths in the route table in order, with the methods
+// registered for each. Paths include PathPrefix, so they are what clients
+// call; GET paths also answer HEAD and every path answers OPTIONS.
+func (s *Server) Routes() []RouteInfo {
+	prefix := strings.TrimSuffix(s.config().PathPrefix, "/")
+	var infos []RouteInfo
+	index := make(map[string]int)
+	for _, rt := range s.routeTable() {
+		i, seen := index[rt.path]
+		if !seen {
+			i = len(infos)
+			index[rt.path] = i
+			infos = append(infos, RouteInfo{
+				Path:        prefix + strings.TrimSuffix(rt.path, "{$}"),
+				Description: rt.description,
+			})
+		}
+		infos[i].Methods = append(infos[i].Methods, rt.method)
+	}
+	return infos
+}
+
+// routesHandler handles GET /_routes requests
+func (s *Server) routesHandler(w http.ResponseWriter, r *http.Request) {
+	s.respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Routes retrieved successfully",
+		Data:    s.Routes(),
+	})
+}
+
+// routes registers the route table on the mux. Each path also answers
+// OPTIONS with 204 and a method-less fallback so wrong methods receive a
+// JSON 405, both with an Allow header, and anything unmatched falls
+// through to a JSON 404.
+func (s *Server) routes() {
+	var paths []string
+	allowed := make(map[string][]string)
+	for _, rt := range s.routeTable() {
+		s.mux.Handle(rt.method+" "+rt.path, s.withTimeout(rt.path, s.withCacheControl(rt.path, rt.handler)))
+		if _, seen := allowed[rt.path]; !seen {
+			paths = append(paths, rt.path)
+		}
+		allowed[rt.path] = append(allowed[rt.path], rt.method)
+	}
+
+	for _, path := range paths {
+		allow := allowHeader(allowed[path])
+		s.mux.Handle(http.MethodOptions+" "+path, options(strings.Join(allow, ", ")))
+		s.mux.Handle(path, s.methodNotAllowed(allow, allowed[path]))
+	}
+	s.mux.HandleFunc("/", s.notFound)
+}
+
+// withTimeout gives a route's requests a context deadline, using the path's
+// entry in routeTimeouts or else Config.HandlerTimeout; 0 means no deadline
+func (s *Server) withTimeout(path string, h http.Handler) http.Handler {
+	timeout, ok := s.routeTimeouts[path]
+	if !ok {
+		timeout = s.config().HandlerTimeout
+	}
+	if timeout <= 0 {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// allowHeader lists every method a path answers given its registered
+// methods: GET patterns also serve HEAD, and every path serves OPTIONS
+func allowHeader(methods []string) []string {
+	allow := append([]string(nil), methods...)
+	for _, method := range methods {
+		if method == http.MethodGet {
+			allow = append(allow, http.MethodHead)
+			break
+		}
+	}
+	return append(allow, http.MethodOptions)
+}
+
+// options responds 204 to OPTIONS with the path's Allow header
+func options(allow string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", allow)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// MethodNotAllowedData represents the data returned with a 405 response
+type MethodNotAllowedData struct {
+	AllowedMethods []string `json:"allowed_methods"`
+}
+
+// methodNotAllowed responds 405 with the Allow header, naming the methods
+// the path was registered with in the message
+func (s *Server) methodNotAllowed(allow, methods []string) http.HandlerFunc {
+	header := strings.Join(allow, ", ")
+	message := fmt.Sprintf("Method not allowed. Use %s.", strings.Join(methods, " or "))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", header)
+		s.respondError(w, &apiError{
+			Status:  http.StatusMethodNotAllowed,
+			Code:    codeMethodNotAllowed,
+			Message: message,
+			Data:    MethodNotAllowedData{AllowedMethods: allow},
+		})
+	}
+}
+
+// notFound responds 404 for paths that match no route
+func (s *Server) notFound(w http.ResponseWriter, r *http.Request) {
+	s.respondError(w, newAPIError(http.StatusNotFound, codeNotFound, "Not found"))
+}
+
+// ServeHTTP runs the request through the middleware chain to the matching route
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,286 @@
+package pingme
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMethodNotAllowed tests that the mux answers wrong methods with JSON 405 and an Allow header
+func TestMethodNotAllowed(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		allow  string
+	}{
+		{http.MethodPost, "/", "GET, HEAD, OPTIONS"},
+		{http.MethodDelete, "/healthz", "GET, HEAD, OPTIONS"},
+		{http.MethodPut, "/echo", "GET, POST, HEAD, OPTIONS"},
+		{http.MethodPut, "/greet/morning", "GET, HEAD, OPTIONS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newTestServer().ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != http.StatusMethodNotAllowed {
+				t.Errorf("expected status 405, got %d", w.Code)
+			}
+			if got := w.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("expected Allow %q, got %q", tt.allow, got)
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("expected JSON 405 body, got Content-Type %q", got)
+			}
+		})
+	}
+}
+
+// TestMethodNotAllowedData tests that the 405 body lists the allowed methods
+func TestMethodNotAllowedData(t *testing.T) {
+	w := httptest.NewRecorder()
+	newTestServer().ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/echo", nil))
+
+	var response struct {
+		Data MethodNotAllowedData `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	expected := []string{"GET", "POST", "HEAD", "OPTIONS"}
+	if strings.Join(response.Data.AllowedMethods, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected allowed_methods %v, got %v", expected, response.Data.AllowedMethods)
+	}
+}
+
+// TestNotFound tests that unmatched paths, including trailing slashes, return JSON 404
+func TestNotFound(t *testing.T) {
+	for _, path := range []string{"/missing", "/healthz/", "/echo/extra"} {
+		t.Run(path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newTestServer().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+			if w.Code != http.StatusNotFound {
+				t.Errorf("expected status 404, got %d", w.Code)
+			}
+
+			var response Response
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Success || response.Error == "" {
+				t.Errorf("expected an error response, got %+v", response)
+			}
+		})
+	}
+}
+
+// TestHeadFollowsGet tests that GET routes also answer HEAD
+func TestHeadFollowsGet(t *testing.T) {
+	w := httptest.NewRecorder()
+	newTestServer().ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/healthz", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200 for HEAD /healthz, got %d", w.Code)
+	}
+}
+
+// TestDisableGreeting tests that DISABLE_GREETING turns / into a 404 and leaves other routes alone
+func TestDisableGreeting(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DisableGreeting = true
+	server := NewServer(cfg)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for /, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected a JSON 404, got Content-Type %q", ct)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"message": "still here"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected /echo to keep working, got %d", w.Code)
+	}
+}
+
+// TestEnableEcho tests that ENABLE_ECHO=false removes every /echo route while /healthz remains
+func TestEnableEcho(t *testing.T) {
+	t.Setenv("ENABLE_ECHO", "false")
+	server := NewServer(LoadConfig())
+
+	for _, path := range []string{"/echo", "/echo/raw", "/echo/ndjson"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"message": "gone"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		assertError(t, w, http.StatusNotFound, codeNotFound)
+	}
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected /healthz to keep working, got %d", w.Code)
+	}
+}
+
+// TestEnableGreeting tests that ENABLE_GREETING=false removes the greeting like DISABLE_GREETING
+func TestEnableGreeting(t *testing.T) {
+	t.Setenv("ENABLE_GREETING", "false")
+	w := httptest.NewRecorder()
+	NewServer(LoadConfig()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assertError(t, w, http.StatusNotFound, codeNotFound)
+}
+
+// TestRouteTimeouts tests that each route gets its own deadline
+func TestRouteTimeouts(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RouteTimeouts = map[string]time.Duration{
+		"/echo":    50 * time.Millisecond,
+		"/healthz": 20 * time.Millisecond,
+	}
+	server := NewServer(cfg)
+
+	// A delay longer than the echo timeout is refused, though HANDLER_TIMEOUT would allow it
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"message": "slow", "delay_ms": 100}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected /echo to reject a delay past its 50ms timeout, got %d", w.Code)
+	}
+
+	var remaining time.Duration
+	server.RegisterHealthCheck("deadline", HealthCheckFunc(func(ctx context.Context) error {
+		deadline, _ := ctx.Deadline()
+		remaining = time.Until(deadline)
+		return nil
+	}))
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if remaining <= 0 || remaining > 20*time.Millisecond {
+		t.Errorf("expected /healthz checks to see its 20ms deadline, got %v remaining", remaining)
+	}
+}
+
+// TestServerTimeoutEnv tests that READ_TIMEOUT and WRITE_TIMEOUT are read from the environment
+func TestServerTimeoutEnv(t *testing.T) {
+	t.Setenv("READ_TIMEOUT", "3s")
+	t.Setenv("WRITE_TIMEOUT", "45s")
+	cfg := LoadConfig()
+	if cfg.ReadTimeout != 3*time.Second {
+		t.Errorf("expected read timeout 3s, got %v", cfg.ReadTimeout)
+	}
+	if cfg.WriteTimeout != 45*time.Second {
+		t.Errorf("expected write timeout 45s, got %v", cfg.WriteTimeout)
+	}
+}
+
+// TestRouteTimeoutEnv tests that ECHO_TIMEOUT overrides the /echo timeout
+func TestRouteTimeoutEnv(t *testing.T) {
+	t.Setenv("ECHO_TIMEOUT", "30s")
+
+	cfg := LoadConfig()
+	if got := cfg.RouteTimeouts["/echo"]; got != 30*time.Second {
+		t.Errorf("expected /echo timeout 30s, got %v", got)
+	}
+	if got := cfg.RouteTimeouts["/healthz"]; got != 2*time.Second {
+		t.Errorf("expected default /healthz timeout 2s, got %v", got)
+	}
+}
+
+// TestOptions tests that OPTIONS on any route returns 204 with its Allow header
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		path  string
+		allow string
+	}{
+		{"/", "GET, HEAD, OPTIONS"},
+		{"/healthz", "GET, HEAD, OPTIONS"},
+		{"/echo", "GET, POST, HEAD, OPTIONS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newTestServer().ServeHTTP(w, httptest.NewRequest(http.MethodOptions, tt.path, nil))
+
+			if w.Code != http.StatusNoContent {
+				t.Errorf("expected status 204, got %d", w.Code)
+			}
+			if got := w.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("expected Allow %q, got %q", tt.allow, got)
+			}
+			if w.Body.Len() != 0 {
+				t.Errorf("expected an empty body, got %q", w.Body.String())
+			}
+		})
+	}
+}
+
+// TestRoutesEndpoint tests that /_routes lists the route table with each path's methods
+func TestRoutesEndpoint(t *testing.T) {
+	w := httptest.NewRecorder()
+	newTestServer().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/_routes", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var response struct {
+		Data []RouteInfo `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	expected := map[string][]string{
+		"/":         {http.MethodGet},
+		"/echo":     {http.MethodGet, http.MethodPost},
+		"/healthz":  {http.MethodGet},
+		"/echo/raw": {http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+	}
+	found := make(map[string]bool)
+	for _, rt := range response.Data {
+		if rt.Description == "" {
+			t.Errorf("expected a description for %s", rt.Path)
+		}
+		if methods, ok := expected[rt.Path]; ok {
+			found[rt.Path] = true
+			if !slices.Equal(rt.Methods, methods) {
+				t.Errorf("expected %s methods %v, got %v", rt.Path, methods, rt.Methods)
+			}
+		}
+	}
+	for path := range expected {
+		if !found[path] {
+			t.Errorf("expected %s in /_routes", path)
+		}
+	}
+}
+
+// TestRoutesPathPrefix tests that listed paths include PATH_PREFIX
+func TestRoutesPathPrefix(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.PathPrefix = "/pingme/"
+
+	routes := NewServer(cfg).Routes()
+
+	if routes[0].Path != "/pingme/" {
+		t.Errorf("expected the greeting at /pingme/, got %s", routes[0].Path)
+	}
+	if routes[1].Path != "/pingme/greet/{name}" {
+		t.Errorf("expected /pingme/greet/{name}, got %s", routes[1].Path)
+	}
+}
@@ -0,0 +1,37 @@
+package pingme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ShutdownHook releases a resource when the server shuts down; it should
+// return promptly once ctx is done
+type ShutdownHook func(ctx context.Context) error
+
+// OnShutdown registers a hook for Shutdown to run. Hooks run in the order
+// they were registered.
+func (s *Server) OnShutdown(hook ShutdownHook) {
+	s.shutdownMu.Lock()
+	defer s.shutdownMu.Unlock()
+	s.shutdownHooks = append(s.shutdownHooks, hook)
+}
+
+// Shutdown runs every registered hook once, after the HTTP server has
+// stopped accepting connections, and returns their errors joined. Later
+// calls do nothing and return nil.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shutdownMu.Lock()
+	hooks := s.shutdownHooks
+	s.shutdownHooks = nil
+	s.shutdownMu.Unlock()
+
+	var errs []error
+	for i, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown hook %d: %w", i+1, err))
+		}
+	}
+	return errors.Join(errs...)
+}
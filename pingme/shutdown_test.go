@@ -0,0 +1,45 @@
+package pingme
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestOnShutdownRunsOnce tests that a registered hook runs exactly once across repeated shutdowns
+func TestOnShutdownRunsOnce(t *testing.T) {
+	server := NewServer(DefaultConfig())
+	calls := 0
+	server.OnShutdown(func(context.Context) error {
+		calls++
+		return nil
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := server.Shutdown(context.Background()); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected the hook to run once, ran %d times", calls)
+	}
+}
+
+// TestShutdownJoinsErrors tests that every hook runs and their errors are aggregated
+func TestShutdownJoinsErrors(t *testing.T) {
+	server := NewServer(DefaultConfig())
+	first := errors.New("flush failed")
+	second := errors.New("close failed")
+	var order []int
+	server.OnShutdown(func(context.Context) error { order = append(order, 1); return first })
+	server.OnShutdown(func(context.Context) error { order = append(order, 2); return nil })
+	server.OnShutdown(func(context.Context) error { order = append(order, 3); return second })
+
+	err := server.Shutdown(context.Background())
+	if !errors.Is(err, first) || !errors.Is(err, second) {
+		t.Errorf("expected both hook errors, got %v", err)
+	}
+	if len(order) != 3 || order[0] != 1 || order[2] != 3 {
+		t.Errorf("expected hooks in registration order, got %v", order)
+	}
+}
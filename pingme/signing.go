@@ -0,0 +1,66 @@
+package pingme
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// signingWriter holds back a response so a signature of the complete body
+// can be sent in a header ahead of it
+type signingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *signingWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *signingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *signingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// signBody returns the hex HMAC-SHA256 of body under key
+func signBody(key string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signed adds an X-Signature: sha256=<hex> header, an HMAC-SHA256 of the
+// serialized body under Config.ResponseSigningKey, when a key is set. The
+// signature covers the body before any gzip Content-Encoding is applied.
+func (s *Server) signed(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := s.config().ResponseSigningKey
+		if key == "" {
+			next(w, r)
+			return
+		}
+
+		sw := &signingWriter{ResponseWriter: w}
+		next(sw, r)
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		w.Header().Set("X-Signature", "sha256="+signBody(key, sw.body.Bytes()))
+		w.WriteHeader(sw.status)
+		if _, err := w.Write(sw.body.Bytes()); err != nil {
+			s.logWriteError("Error writing signed response", err)
+		}
+	}
+}
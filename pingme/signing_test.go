@@ -0,0 +1,42 @@
+package pingme
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+)
+
+// TestSignedEchoResponse tests that X-Signature verifies against the body with the key
+func TestSignedEchoResponse(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ResponseSigningKey = "signing-key"
+	server := NewServer(cfg)
+	server.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	w := serve(server, http.MethodPost, "/echo", `{"message": "hi"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	mac := hmac.New(sha256.New, []byte("signing-key"))
+	mac.Write(w.Body.Bytes())
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := w.Header().Get("X-Signature"); got != want {
+		t.Errorf("expected X-Signature %q, got %q", want, got)
+	}
+}
+
+// TestUnsignedEchoResponse tests that no signature is sent without a key
+func TestUnsignedEchoResponse(t *testing.T) {
+	server := NewServer(DefaultConfig())
+	server.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	w := serve(server, http.MethodPost, "/echo", `{"message": "hi"}`)
+	if got := w.Header().Get("X-Signature"); got != "" {
+		t.Errorf("expected no X-Signature, got %q", got)
+	}
+}
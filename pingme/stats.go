@@ -0,0 +1,34 @@
+package pingme
+
+import "math"
+
+// charFrequency counts how often each rune occurs in message, keyed by the
+// character itself so multibyte runes are counted once each
+func charFrequency(message string) map[string]int {
+	counts := make(map[string]int)
+	for _, r := range message {
+		counts[string(r)]++
+	}
+	return counts
+}
+
+// messageStats returns the Shannon entropy of message in bits per character
+// and the number of distinct characters, both computed over runes
+func messageStats(message string) (float64, int) {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range message {
+		counts[r]++
+		total++
+	}
+	if total == 0 {
+		return 0, 0
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy, len(counts)
+}
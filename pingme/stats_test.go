@@ -0,0 +1,78 @@
+package pingme
+
+import (
+	"math"
+	"net/http"
+	"testing"
+)
+
+// TestMessageStats tests entropy of uniform versus repeated-character input
+func TestMessageStats(t *testing.T) {
+	tests := []struct {
+		message string
+		entropy float64
+		unique  int
+	}{
+		{"aaaaaaaa", 0, 1},
+		{"abcdefgh", 3, 8},
+		{"abab", 1, 2},
+		{"日本日本", 1, 2},
+	}
+
+	for _, tt := range tests {
+		entropy, unique := messageStats(tt.message)
+		if math.Abs(entropy-tt.entropy) > 1e-9 {
+			t.Errorf("messageStats(%q): expected entropy %v, got %v", tt.message, tt.entropy, entropy)
+		}
+		if unique != tt.unique {
+			t.Errorf("messageStats(%q): expected %d unique chars, got %d", tt.message, tt.unique, unique)
+		}
+	}
+}
+
+// TestEchoStats tests that stats are only returned when requested
+func TestEchoStats(t *testing.T) {
+	status, response := postEcho(t, `{"message": "aaaa", "stats": true}`)
+	if status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+
+	dataMap := response.Data.(map[string]interface{})
+	if entropy, ok := dataMap["entropy"].(float64); !ok || entropy != 0 {
+		t.Errorf("expected entropy 0 for a repeated character, got %v", dataMap["entropy"])
+	}
+	if unique, ok := dataMap["unique_chars"].(float64); !ok || unique != 1 {
+		t.Errorf("expected unique_chars 1, got %v", dataMap["unique_chars"])
+	}
+
+	_, response = postEcho(t, `{"message": "aaaa"}`)
+	dataMap = response.Data.(map[string]interface{})
+	if _, ok := dataMap["entropy"]; ok {
+		t.Error("expected no entropy field without stats")
+	}
+	if _, ok := dataMap["char_frequency"]; ok {
+		t.Error("expected no char_frequency field without stats")
+	}
+}
+
+// TestEchoCharFrequency tests that character counts are exact for repeated and multibyte runes
+func TestEchoCharFrequency(t *testing.T) {
+	status, response := postEcho(t, `{"message": "héllo 日本日本 ééé", "stats": true}`)
+	if status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+
+	freq, ok := response.Data.(map[string]interface{})["char_frequency"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected a char_frequency object, got %v", response.Data)
+	}
+	expected := map[string]float64{"h": 1, "é": 4, "l": 2, "o": 1, " ": 2, "日": 2, "本": 2}
+	if len(freq) != len(expected) {
+		t.Errorf("expected %d distinct characters, got %d: %v", len(expected), len(freq), freq)
+	}
+	for char, count := range expected {
+		if got, _ := freq[char].(float64); got != count {
+			t.Errorf("expected %q to appear %v times, got %v", char, count, freq[char])
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package pingme
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// echoTransform rewrites a message for the echo endpoint, failing if the
+// message isn't valid input for the mode. Modes that report on their work,
+// such as mask counting redactions, record it in stats as the chain runs.
+type echoTransform func(message string, stats *transformStats) (string, error)
+
+// transformStats is what the modes in a chain reported about the text each
+// one received; a field stays nil unless a mode that sets it ran
+type transformStats struct {
+	redactions           *int
+	trimmedChars         *int
+	normalizationChanged *bool
+}
+
+// echoTransforms lists the modes accepted in EchoRequest.Mode
+var echoTransforms = map[string]echoTransform{
+	"upper":     infallible(strings.ToUpper),
+	"lower":     infallible(strings.ToLower),
+	"reverse":   infallible(reverseString),
+	"rot13":     infallible(rot13),
+	"hex":       infallible(hexEncode),
+	"hexdecode": fallible(hexDecode),
+	"mask":      maskTransform,
+	"sha256":    infallible(sha256Hex),
+	"trim":      trimTransform,
+	"nfc":       normalizeTransform(norm.NFC),
+	"nfd":       normalizeTransform(norm.NFD),
+}
+
+// sensitivePatterns match the values the mask mode redacts, in the order they
+// are applied; bearer tokens go first so their digits aren't taken for a card
+var sensitivePatterns = []struct {
+	re          *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9._~+/-]+=*`), "${1}***"},
+	{regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), "***"},
+	{regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), "***"},
+}
+
+// maskSensitive replaces emails, card-like numbers and bearer tokens with
+// "***", returning the masked message and the number of redactions
+func maskSensitive(s string) (string, int) {
+	count := 0
+	for _, p := range sensitivePatterns {
+		count += len(p.re.FindAllStringIndex(s, -1))
+		s = p.re.ReplaceAllString(s, p.replacement)
+	}
+	return s, count
+}
+
+// maskTransform is maskSensitive as a transform, adding its count to the
+// redactions, so with "hex,mask" nothing is found in the hex text
+func maskTransform(s string, stats *transformStats) (string, error) {
+	masked, count := maskSensitive(s)
+	stats.redactions = addCount(stats.redactions, count)
+	return masked, nil
+}
+
+// addCount adds n to a count a mode may not have started yet
+func addCount(count *int, n int) *int {
+	if count == nil {
+		count = new(int)
+	}
+	*count += n
+	return count
+}
+
+// fallible adapts a transform that may reject the message but reports nothing
+func fallible(f func(string) (string, error)) echoTransform {
+	return func(s string, _ *transformStats) (string, error) {
+		return f(s)
+	}
+}
+
+// infallible adapts a transform that accepts any message and reports nothing
+func infallible(f func(string) string) echoTransform {
+	return func(s string, _ *transformStats) (string, error) {
+		return f(s), nil
+	}
+}
+
+// collapseWhitespace trims the message and turns each internal run of
+// whitespace, including tabs and newlines, into a single space
+func collapseWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// trimTransform is collapseWhitespace as a transform, adding how many
+// characters it removed to the trimmed count
+func trimTransform(s string, stats *transformStats) (string, error) {
+	trimmed := collapseWhitespace(s)
+	stats.trimmedChars = addCount(stats.trimmedChars, utf8.RuneCountInString(s)-utf8.RuneCountInString(trimmed))
+	return trimmed, nil
+}
+
+// normalizeTransform returns the transform for a normalization form, noting
+// whether it changed the bytes of the text it received, that is whether that
+// text wasn't already in the form
+func normalizeTransform(form norm.Form) echoTransform {
+	return func(s string, stats *transformStats) (string, error) {
+		changed := !form.IsNormalString(s)
+		if stats.normalizationChanged != nil {
+			changed = changed || *stats.normalizationChanged
+		}
+		stats.normalizationChanged = &changed
+		return form.String(s), nil
+	}
+}
+
+// hexEncode returns the lowercase hex encoding of the message's UTF-8 bytes
+func hexEncode(s string) string {
+	return hex.EncodeToString([]byte(s))
+}
+
+// sha256Hex returns the lowercase hex SHA-256 digest of the message's UTF-8 bytes
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// hexDecode parses hex input back into text
+func hexDecode(s string) (string, error) {
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid hex: %v", err)
+	}
+	return string(decoded), nil
+}
+
+// reverseString reverses a string rune by rune so multibyte characters stay intact
+func reverseString(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+// rot13 rotates ASCII letters by 13 places. Non-ASCII letters such as "é" are
+// left untouched, so applying it twice always yields the original message.
+func rot13(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return 'a' + (r-'a'+13)%26
+		case r >= 'A' && r <= 'Z':
+			return 'A' + (r-'A'+13)%26
+		default:
+			return r
+		}
+	}, s)
+}
+
+// parseModes splits a comma-separated mode list into transforms applied left-to-right
+func parseModes(mode string) ([]echoTransform, error) {
+	var transforms []echoTransform
+	for _, name := range strings.Split(mode, ",") {
+		name = strings.TrimSpace(name)
+		transform, ok := echoTransforms[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown echo mode %q", name)
+		}
+		transforms = append(transforms, transform)
+	}
+	return transforms, nil
+}
+
+// hasMode reports whether a comma-separated mode list includes name
+func hasMode(mode, name string) bool {
+	for _, token := range strings.Split(mode, ",") {
+		if strings.TrimSpace(token) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTransforms runs each transform over the message in order, stopping at
+// the first failure, and returns what the modes reported along the way
+func applyTransforms(message string, transforms []echoTransform) (string, transformStats, error) {
+	var stats transformStats
+	for _, transform := range transforms {
+		var err error
+		if message, err = transform(message, &stats); err != nil {
+			return "", transformStats{}, err
+		}
+	}
+	return message, stats, nil
+}
@@ -0,0 +1,332 @@
+package pingme
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// postEcho sends a JSON body to echoHandler and returns the decoded response
+func postEcho(t *testing.T, body string) (int, Response) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	var response Response
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return res.StatusCode, response
+}
+
+// echoedValue extracts the echoed field from a successful echo response
+func echoedValue(t *testing.T, response Response) string {
+	t.Helper()
+
+	dataMap, ok := response.Data.(map[string]interface{})
+	if !ok {
+		t.Fatal("expected data to be a map")
+	}
+	echoed, _ := dataMap["echoed"].(string)
+	return echoed
+}
+
+// TestEchoModes tests single and chained echo modes
+func TestEchoModes(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		expected string
+	}{
+		{"single mode", "upper", "HELLO, WORLD"},
+		{"two-mode chain", "upper,reverse", "DLROW ,OLLEH"},
+		{"spaces around tokens", "reverse, lower", "dlrow ,olleh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := postEcho(t, `{"message": "Hello, World", "mode": "`+tt.mode+`"}`)
+
+			if status != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", status)
+			}
+
+			if echoed := echoedValue(t, response); echoed != tt.expected {
+				t.Errorf("expected echoed %q, got %q", tt.expected, echoed)
+			}
+		})
+	}
+}
+
+// TestEchoModesInvalidToken tests that an unknown mode in a chain is rejected by name
+func TestEchoModesInvalidToken(t *testing.T) {
+	status, response := postEcho(t, `{"message": "Hello", "mode": "upper,shout"}`)
+
+	if status != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", status)
+	}
+
+	if response.Success {
+		t.Error("expected success to be false for unknown mode")
+	}
+
+	if !strings.Contains(response.Error, `"shout"`) {
+		t.Errorf("expected error to name the unknown mode, got %q", response.Error)
+	}
+}
+
+// TestRot13 tests ROT13 output, including untouched non-ASCII letters
+func TestRot13(t *testing.T) {
+	tests := map[string]string{
+		"Hello, World!": "Uryyb, Jbeyq!",
+		"abcxyzABCXYZ":  "nopklmNOPKLM",
+		"café 123":      "pnsé 123",
+	}
+
+	for input, expected := range tests {
+		if got := rot13(input); got != expected {
+			t.Errorf("rot13(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+}
+
+// TestEchoModeRot13RoundTrip tests that chaining rot13 twice returns the original
+func TestEchoModeRot13RoundTrip(t *testing.T) {
+	status, response := postEcho(t, `{"message": "Héllo, Wörld", "mode": "rot13,rot13"}`)
+
+	if status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+
+	if echoed := echoedValue(t, response); echoed != "Héllo, Wörld" {
+		t.Errorf("expected round-trip to return the original, got %q", echoed)
+	}
+}
+
+// TestReverseStringMultibyte tests that reversing keeps multibyte characters intact
+func TestReverseStringMultibyte(t *testing.T) {
+	if got := reverseString("héllo 👋"); got != "👋 olléh" {
+		t.Errorf("expected %q, got %q", "👋 olléh", got)
+	}
+}
+
+// TestEchoModeHex tests hex encoding and decoding of the message bytes
+func TestEchoModeHex(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		mode     string
+		expected string
+	}{
+		{"encode", "Hi é", "hex", "486920c3a9"},
+		{"decode", "486920c3a9", "hexdecode", "Hi é"},
+		{"decode uppercase", "4869", "hexdecode", "Hi"},
+		{"round trip", "héllo", "hex,hexdecode", "héllo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := postEcho(t, `{"message": "`+tt.message+`", "mode": "`+tt.mode+`"}`)
+
+			if status != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", status)
+			}
+			if echoed := echoedValue(t, response); echoed != tt.expected {
+				t.Errorf("expected echoed %q, got %q", tt.expected, echoed)
+			}
+		})
+	}
+}
+
+// TestEchoModeHexDecodeInvalid tests that malformed hex is rejected with a 400
+func TestEchoModeHexDecodeInvalid(t *testing.T) {
+	for _, message := range []string{"zz", "abc"} {
+		status, response := postEcho(t, `{"message": "`+message+`", "mode": "hexdecode"}`)
+
+		if status != http.StatusBadRequest {
+			t.Errorf("message %q: expected status 400, got %d", message, status)
+		}
+		if !strings.Contains(response.Error, "invalid hex") {
+			t.Errorf("message %q: expected invalid hex error, got %q", message, response.Error)
+		}
+	}
+}
+
+// TestEchoModeMask tests that mask mode redacts sensitive values and counts them
+func TestEchoModeMask(t *testing.T) {
+	tests := []struct {
+		name       string
+		message    string
+		expected   string
+		redactions float64
+	}{
+		{"email", "contact jane.doe@example.com today", "contact *** today", 1},
+		{"card", "card 4111 1111 1111 1111 declined", "card *** declined", 1},
+		{"bearer", "Authorization: Bearer abc.123-XYZ", "Authorization: Bearer ***", 1},
+		{"several", "a@b.io paid with 4111-1111-1111-1111", "*** paid with ***", 2},
+		{"nothing", "all clear", "all clear", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := postEcho(t, `{"message": "`+tt.message+`", "mode": "mask"}`)
+
+			if status != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", status)
+			}
+			if echoed := echoedValue(t, response); echoed != tt.expected {
+				t.Errorf("expected echoed %q, got %q", tt.expected, echoed)
+			}
+			dataMap := response.Data.(map[string]interface{})
+			if got, ok := dataMap["redactions"].(float64); !ok || got != tt.redactions {
+				t.Errorf("expected %v redactions, got %v", tt.redactions, dataMap["redactions"])
+			}
+		})
+	}
+}
+
+// TestEchoModeMaskChained tests that mask counts what it finds in the text it
+// receives from the modes before it, not in the original message
+func TestEchoModeMaskChained(t *testing.T) {
+	tests := []struct {
+		name       string
+		message    string
+		mode       string
+		redactions float64
+	}{
+		{"hex hides the email", "a@b.io", "hex,mask", 0},
+		{"hexdecode reveals the email", "6140622e696f", "hexdecode,mask", 1},
+		{"masking twice adds nothing", "a@b.io", "mask,mask", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := postEcho(t, `{"message": "`+tt.message+`", "mode": "`+tt.mode+`"}`)
+
+			if status != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", status)
+			}
+			dataMap := response.Data.(map[string]interface{})
+			if got, ok := dataMap["redactions"].(float64); !ok || got != tt.redactions {
+				t.Errorf("expected %v redactions, got %v", tt.redactions, dataMap["redactions"])
+			}
+		})
+	}
+}
+
+// TestEchoModeSHA256 tests that sha256 mode echoes the hex digest, alone and chained
+func TestEchoModeSHA256(t *testing.T) {
+	tests := []struct {
+		mode     string
+		expected string
+	}{
+		{"sha256", "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{"upper,sha256", "3733cd977ff8eb18b987357e22ced99f46097f31ecb239e878ae63760e83e4d5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			status, response := postEcho(t, `{"message": "hello", "mode": "`+tt.mode+`"}`)
+
+			if status != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", status)
+			}
+			if echoed := echoedValue(t, response); echoed != tt.expected {
+				t.Errorf("expected echoed %q, got %q", tt.expected, echoed)
+			}
+		})
+	}
+}
+
+// TestEchoModeTrim tests that trim mode normalizes whitespace and counts what
+// it removed from the text it received, after any modes before it
+func TestEchoModeTrim(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		mode     string
+		expected string
+		trimmed  float64
+	}{
+		{"leading and trailing", `  hello  `, "trim", "hello", 4},
+		{"internal", `hello   big\t\nworld`, "trim", "hello big world", 3},
+		{"clean", "hello world", "trim", "hello world", 0},
+		{"after hex", "a  b", "hex,trim", "61202062", 0},
+		{"after hexdecode", "61202062", "hexdecode,trim", "a b", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := postEcho(t, `{"message": "`+tt.message+`", "mode": "`+tt.mode+`"}`)
+
+			if status != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", status)
+			}
+			if echoed := echoedValue(t, response); echoed != tt.expected {
+				t.Errorf("expected echoed %q, got %q", tt.expected, echoed)
+			}
+			dataMap := response.Data.(map[string]interface{})
+			if got, ok := dataMap["trimmed_chars"].(float64); !ok || got != tt.trimmed {
+				t.Errorf("expected %v trimmed_chars, got %v", tt.trimmed, dataMap["trimmed_chars"])
+			}
+		})
+	}
+}
+
+// TestEchoModeNormalization tests that nfc recomposes a decomposed
+// character, nfd decomposes it, and text already in the form it receives is reported unchanged
+func TestEchoModeNormalization(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		mode     string
+		expected string
+		changed  bool
+	}{
+		{"nfc recomposes", `cafe\u0301`, "nfc", "caf\u00e9", true},
+		{"nfc already normalized", `caf\u00e9`, "nfc", "caf\u00e9", false},
+		{"nfd decomposes", `caf\u00e9`, "nfd", "cafe\u0301", true},
+		{"nfd already normalized", `cafe\u0301`, "nfd", "cafe\u0301", false},
+		{"ascii", "hello", "nfc", "hello", false},
+		{"after hex", `cafe\u0301`, "hex,nfc", "63616665cc81", false},
+		{"nfd then nfc", `caf\u00e9`, "nfd,nfc", "caf\u00e9", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := postEcho(t, `{"message": "`+tt.message+`", "mode": "`+tt.mode+`"}`)
+
+			if status != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", status)
+			}
+			if echoed := echoedValue(t, response); echoed != tt.expected {
+				t.Errorf("expected echoed %+q, got %+q", tt.expected, echoed)
+			}
+			dataMap := response.Data.(map[string]interface{})
+			if got, ok := dataMap["normalization_changed"].(bool); !ok || got != tt.changed {
+				t.Errorf("expected normalization_changed %v, got %v", tt.changed, dataMap["normalization_changed"])
+			}
+		})
+	}
+}
+
+// TestEchoModeTrimWhitespaceOnly tests that a message trimmed to nothing is rejected as empty
+func TestEchoModeTrimWhitespaceOnly(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewBufferString(`{"message": " \t\n ", "mode": "trim"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	assertError(t, w, http.StatusBadRequest, codeEmptyMessage)
+}
@@ -0,0 +1,59 @@
+package pingme
+
+import (
+	"net/http"
+	"runtime"
+	"time"
+)
+
+// Build information, overridable at link time, for example:
+//
+//	go build -ldflags "-X github.com/Caleb125-source/pingme-api/pingme.BuildDate=2024-02-15T10:30:00Z"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildDate = ""
+)
+
+// VersionData represents the data returned by the version endpoint
+type VersionData struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// buildTime parses BuildDate, reporting false when it is unset or malformed
+func buildTime() (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339, BuildDate)
+	if err != nil {
+		return time.Time{}, false
+	}
+	// HTTP dates have one-second resolution
+	return t.UTC().Truncate(time.Second), true
+}
+
+// versionHandler handles GET /version requests
+func (s *Server) versionHandler(w http.ResponseWriter, r *http.Request) {
+	// Build info only changes per deployment, so let clients revalidate cheaply
+	if built, ok := buildTime(); ok {
+		w.Header().Set("Last-Modified", built.Format(http.TimeFormat))
+		if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !built.After(since) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	data := VersionData{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+
+	s.respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Version retrieved successfully",
+		Data:    data,
+	})
+}
@@ -0,0 +1,85 @@
+package pingme
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// withBuildDate sets BuildDate for the duration of a test
+func withBuildDate(t *testing.T, date string) {
+	t.Helper()
+	previous := BuildDate
+	BuildDate = date
+	t.Cleanup(func() { BuildDate = previous })
+}
+
+// TestVersionHandlerLastModified tests that a fresh request carries Last-Modified
+func TestVersionHandlerLastModified(t *testing.T) {
+	withBuildDate(t, "2024-02-15T10:30:00Z")
+
+	req := httptest.NewRequest(http.MethodGet, "/version", nil)
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+
+	if got := w.Header().Get("Last-Modified"); got != "Thu, 15 Feb 2024 10:30:00 GMT" {
+		t.Errorf("expected Last-Modified of the build date, got %q", got)
+	}
+}
+
+// TestVersionHandlerNotModified tests conditional requests against the build date
+func TestVersionHandlerNotModified(t *testing.T) {
+	withBuildDate(t, "2024-02-15T10:30:00Z")
+
+	tests := []struct {
+		name     string
+		since    string
+		expected int
+	}{
+		{"same time", "Thu, 15 Feb 2024 10:30:00 GMT", http.StatusNotModified},
+		{"later time in RFC 850 format", "Friday, 16-Feb-24 08:00:00 GMT", http.StatusNotModified},
+		{"older copy", "Wed, 14 Feb 2024 10:30:00 GMT", http.StatusOK},
+		{"unparseable date", "yesterday", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/version", nil)
+			req.Header.Set("If-Modified-Since", tt.since)
+			w := httptest.NewRecorder()
+
+			newTestServer().ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("expected status %d, got %d", tt.expected, w.Code)
+			}
+			if tt.expected == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("expected empty body on 304, got %q", w.Body.String())
+			}
+		})
+	}
+}
+
+// TestVersionHandlerNoBuildDate tests that unstamped builds are never treated as cached
+func TestVersionHandlerNoBuildDate(t *testing.T) {
+	withBuildDate(t, "")
+
+	req := httptest.NewRequest(http.MethodGet, "/version", nil)
+	req.Header.Set("If-Modified-Since", time.Now().UTC().Format(http.TimeFormat))
+	w := httptest.NewRecorder()
+
+	newTestServer().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("Last-Modified"); got != "" {
+		t.Errorf("expected no Last-Modified header, got %q", got)
+	}
+}
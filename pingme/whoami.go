@@ -0,0 +1,126 @@
+package pingme
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// WhoAmIData represents the data returned by the whoami endpoint
+type WhoAmIData struct {
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+	Protocol  string `json:"protocol"`
+}
+
+// remoteIP returns the host part of the request's RemoteAddr
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// parseTrustedProxies parses TRUSTED_PROXIES entries, accepting CIDRs such
+// as "10.0.0.0/8" and bare IPs, which trust that single address
+func parseTrustedProxies(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("TRUSTED_PROXIES: %q is not a CIDR or IP address", entry)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// trusted reports whether ip belongs to one of the trusted proxy ranges
+func (s *Server) trusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range s.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedFor walks the X-Forwarded-For chain from the nearest hop outwards
+// and returns the first address not in a trusted range, so a client can't
+// hide behind entries it prepended itself. It returns "" without a header.
+func (s *Server) forwardedFor(r *http.Request) string {
+	var hops []string
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		if i == 0 || !s.trusted(hops[i]) {
+			return hops[i]
+		}
+	}
+	return ""
+}
+
+// clientIP resolves the originating client IP. Forwarded headers are only
+// consulted when the peer is a trusted proxy, since any client can forge
+// them: with TrustedProxies set the peer must be in one of its ranges,
+// otherwise TrustProxy trusts every peer.
+func (s *Server) clientIP(r *http.Request) string {
+	if len(s.trustedProxies) > 0 {
+		peer := remoteIP(r)
+		if !s.trusted(peer) {
+			return peer
+		}
+		if ip := s.forwardedFor(r); ip != "" {
+			return ip
+		}
+		if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+			return realIP
+		}
+		return peer
+	}
+	if s.config().TrustProxy {
+		// The left-most X-Forwarded-For entry is the original client
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			if ip := strings.TrimSpace(first); ip != "" {
+				return ip
+			}
+		}
+		if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+			return realIP
+		}
+	}
+	return remoteIP(r)
+}
+
+// whoamiHandler handles GET /whoami requests
+func (s *Server) whoamiHandler(w http.ResponseWriter, r *http.Request) {
+	data := WhoAmIData{
+		IP:        s.clientIP(r),
+		UserAgent: r.UserAgent(),
+		Protocol:  r.Proto,
+	}
+
+	s.respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Client details retrieved successfully",
+		Data:    data,
+	})
+}
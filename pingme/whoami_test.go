@@ -0,0 +1,137 @@
+package pingme
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// whoami sends a request to the whoami endpoint and returns the decoded data
+func whoami(t *testing.T, server *Server, req *http.Request) WhoAmIData {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Data WhoAmIData `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return response.Data
+}
+
+// TestWhoAmIDirect tests a direct connection, ignoring forged forwarding headers
+func TestWhoAmIDirect(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+	req.Header.Set("User-Agent", "pingme-test/1.0")
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	data := whoami(t, newTestServer(), req)
+
+	if data.IP != "203.0.113.7" {
+		t.Errorf("expected IP 203.0.113.7, got %s", data.IP)
+	}
+	if data.UserAgent != "pingme-test/1.0" {
+		t.Errorf("expected user agent pingme-test/1.0, got %s", data.UserAgent)
+	}
+	if data.Protocol != "HTTP/1.1" {
+		t.Errorf("expected protocol HTTP/1.1, got %s", data.Protocol)
+	}
+}
+
+// TestWhoAmIForwarded tests forwarded headers when the proxy is trusted
+func TestWhoAmIForwarded(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TrustProxy = true
+	server := NewServer(cfg)
+
+	tests := []struct {
+		name     string
+		header   string
+		value    string
+		expected string
+	}{
+		{"X-Forwarded-For chain", "X-Forwarded-For", "198.51.100.1, 10.0.0.2", "198.51.100.1"},
+		{"X-Real-IP", "X-Real-IP", "198.51.100.9", "198.51.100.9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+			req.RemoteAddr = "10.0.0.2:8080"
+			req.Header.Set(tt.header, tt.value)
+
+			if data := whoami(t, server, req); data.IP != tt.expected {
+				t.Errorf("expected IP %s, got %s", tt.expected, data.IP)
+			}
+		})
+	}
+}
+
+// TestWhoAmITrustedProxies tests that forwarded headers are only honoured from trusted peers
+func TestWhoAmITrustedProxies(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TrustedProxies = []string{"10.0.0.0/8", "192.0.2.10"}
+	server := NewServer(cfg)
+
+	tests := []struct {
+		name      string
+		peer      string
+		forwarded string
+		expected  string
+	}{
+		{"trusted proxy", "10.0.0.2:8080", "198.51.100.1", "198.51.100.1"},
+		{"trusted proxy chain", "10.0.0.2:8080", "198.51.100.1, 192.0.2.10, 10.1.1.1", "198.51.100.1"},
+		{"client prepends a spoofed hop", "10.0.0.2:8080", "203.0.113.99, 198.51.100.1", "198.51.100.1"},
+		{"every hop trusted", "10.0.0.2:8080", "10.3.3.3, 10.1.1.1", "10.3.3.3"},
+		{"untrusted peer spoofing the header", "203.0.113.7:51234", "198.51.100.1", "203.0.113.7"},
+		{"single trusted IP", "192.0.2.10:443", "198.51.100.5", "198.51.100.5"},
+		{"neighbour of a single trusted IP", "192.0.2.11:443", "198.51.100.5", "192.0.2.11"},
+		{"trusted proxy without the header", "10.0.0.2:8080", "", "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+			req.RemoteAddr = tt.peer
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+
+			if data := whoami(t, server, req); data.IP != tt.expected {
+				t.Errorf("expected IP %s, got %s", tt.expected, data.IP)
+			}
+		})
+	}
+}
+
+// TestConfigValidateTrustedProxies tests that unparseable TRUSTED_PROXIES entries are rejected
+func TestConfigValidateTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		valid   bool
+	}{
+		{"CIDRs and IPs", []string{"10.0.0.0/8", "2001:db8::/32", "192.0.2.10"}, true},
+		{"hostname", []string{"proxy.internal"}, false},
+		{"bad prefix length", []string{"10.0.0.0/33"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.TrustedProxies = tt.proxies
+			if err := cfg.Validate(); (err == nil) != tt.valid {
+				t.Errorf("expected valid=%v, got error %v", tt.valid, err)
+			}
+		})
+	}
+}
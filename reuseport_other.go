@@ -0,0 +1,14 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+)
+
+// reusePort fails the listen: SO_REUSEPORT is only set on Linux and the BSDs
+func reusePort(network, address string, conn syscall.RawConn) error {
+	return fmt.Errorf("REUSE_PORT is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,7 @@
+//go:build 386 || amd64 || arm
+
+package main
+
+// soReusePort is SO_REUSEPORT, which the frozen syscall package doesn't
+// define for these architectures
+const soReusePort = 0xf
@@ -0,0 +1,46 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/Caleb125-source/pingme-api/pingme"
+)
+
+// TestListenConfigReusePort tests that two listeners can share a port only with REUSE_PORT on
+func TestListenConfigReusePort(t *testing.T) {
+	cfg := pingme.DefaultConfig()
+	cfg.ReusePort = true
+	ctx := context.Background()
+
+	first, err := listenConfig(cfg).Listen(ctx, "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to bind first listener: %v", err)
+	}
+	defer first.Close()
+	addr := first.Addr().String()
+
+	second, err := listenConfig(cfg).Listen(ctx, "tcp", addr)
+	if err != nil {
+		t.Fatalf("expected a second listener on %s, got %v", addr, err)
+	}
+	second.Close()
+
+	cfg.ReusePort = false
+	if _, err := listenConfig(cfg).Listen(ctx, "tcp", addr); !errors.Is(err, syscall.EADDRINUSE) {
+		t.Errorf("expected EADDRINUSE without REUSE_PORT, got %v", err)
+	}
+}
+
+// TestListenConfigDefault tests that the listener sets no socket options unless asked
+func TestListenConfigDefault(t *testing.T) {
+	if lc := listenConfig(pingme.DefaultConfig()); lc.Control != nil {
+		t.Error("expected no Control function by default")
+	}
+	var _ net.ListenConfig = *listenConfig(pingme.DefaultConfig())
+}